@@ -1,16 +1,25 @@
 // @title Online Subscriptions API
 // @version 1.0
-// @description REST API for managing user online subscriptions
+// @description REST API for managing user online subscriptions.
+// @description Requests authenticate with an API key in the X-API-Key header or a bearer JWT and act for the organisation of that principal.
+// @description Keys and tokens need the read scope for GET requests and the write scope for the others.
+// @description Users act on their own subscriptions, analysts read aggregates and admins do everything; 403 responses carry a reason.
+// @description Subscription reads return an ETag; updates and deletes must send it back in If-Match and get 412 when it is stale.
 // @host localhost:8080
 // @BasePath /
 // @schemes http
 package main
 
 import (
+	"context"
+	"os"
+
 	"github.com/agl/online_subs/internal/application/service"
+	"github.com/agl/online_subs/internal/auth"
 	"github.com/agl/online_subs/internal/infrastructure/repo"
 	"github.com/agl/online_subs/internal/presentation/controllers"
 	"github.com/agl/online_subs/pkg/bootstrap/connections"
+	"github.com/agl/online_subs/pkg/logger"
 )
 
 func main() {
@@ -18,10 +27,44 @@ func main() {
 	defer db.Close()
 
 	repo_pg := repo.NewSubsRepo(db)
+	rates_repo_pg := repo.NewExchangeRatesRepo(db)
+	services_repo_pg := repo.NewServicesRepo(db)
+	api_keys_repo_pg := repo.NewAPIKeysRepo(db)
+	import_jobs_repo_pg := repo.NewImportJobsRepo(db)
+
+	authenticators := []auth.Authenticator{auth.NewAPIKeyAuthenticator(api_keys_repo_pg)}
+
+	jwt_authenticator, err := auth.NewJWTAuthenticator()
+	if err != nil {
+		logger.Log.Error("Failed to configure JWT authentication", "error", err)
+		os.Exit(1)
+	}
+
+	if jwt_authenticator != nil {
+		authenticators = append(authenticators, jwt_authenticator)
+	}
+
+	rates_service := service.NewExchangeRateService(rates_repo_pg)
+	catalog_service := service.NewCatalogService(services_repo_pg)
+	subs_service := service.NewSubsService(repo_pg, services_repo_pg)
+	import_service := service.NewImportService(import_jobs_repo_pg, subs_service)
+
+	if err := import_service.FailInterruptedImports(context.Background()); err != nil {
+		logger.Log.Error("Failed to clean up interrupted imports", "error", err)
+	}
+
+	purger, err := service.NewSubscriptionPurger(repo_pg)
+	if err != nil {
+		logger.Log.Error("Failed to configure subscription purge", "error", err)
+		os.Exit(1)
+	}
 
-	service := service.NewSubsService(repo_pg)
+	go purger.Run(context.Background())
 
-	controller := controllers.NewSubsController(service)
+	rates_controller := controllers.NewExchangeRatesController(rates_service)
+	services_controller := controllers.NewServicesController(catalog_service)
+	imports_controller := controllers.NewImportsController(import_service)
+	controller := controllers.NewSubsController(subs_service, authenticators, rates_controller, services_controller, imports_controller)
 
 	controller.StartServer()
 }
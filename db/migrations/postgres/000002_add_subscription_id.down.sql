@@ -0,0 +1,3 @@
+ALTER TABLE Subscriptions DROP CONSTRAINT IF EXISTS subscriptions_pkey;
+
+ALTER TABLE Subscriptions DROP COLUMN IF EXISTS id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE Subscriptions ADD COLUMN IF NOT EXISTS id UUID NOT NULL DEFAULT uuid_generate_v4();
+
+ALTER TABLE Subscriptions ADD CONSTRAINT subscriptions_pkey PRIMARY KEY (id);
@@ -0,0 +1,5 @@
+ALTER TABLE Subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_billing_interval_check,
+    DROP CONSTRAINT IF EXISTS subscriptions_billing_period_check,
+    DROP COLUMN IF EXISTS billing_interval,
+    DROP COLUMN IF EXISTS billing_period;
\ No newline at end of file
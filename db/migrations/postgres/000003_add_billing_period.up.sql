@@ -0,0 +1,7 @@
+ALTER TABLE Subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period TEXT NOT NULL DEFAULT 'month',
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE Subscriptions
+    ADD CONSTRAINT subscriptions_billing_period_check CHECK (billing_period IN ('week', 'month', 'quarter', 'year')),
+    ADD CONSTRAINT subscriptions_billing_interval_check CHECK (billing_interval > 0);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE Subscriptions DROP COLUMN IF EXISTS currency;
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS subscription_pauses;
+
+ALTER TABLE Subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_status_check,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS trial_end_date,
+    DROP COLUMN IF EXISTS status;
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE Subscriptions
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS trial_end_date DATE,
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
+
+ALTER TABLE Subscriptions
+    ADD CONSTRAINT subscriptions_status_check CHECK (status IN ('trial', 'active', 'paused', 'cancelled', 'expired'));
+
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES Subscriptions(id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    resumed_on DATE,
+    CHECK (resumed_on IS NULL OR resumed_on >= paused_from)
+);
+
+CREATE INDEX idx_subscription_pauses_subscription ON subscription_pauses(subscription_id);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS subscription_price_changes (
+    subscription_id UUID NOT NULL REFERENCES Subscriptions(id) ON DELETE CASCADE,
+    effective_month DATE NOT NULL CHECK (effective_month = date_trunc('month', effective_month)),
+    price INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, effective_month)
+);
+
+INSERT INTO subscription_price_changes (subscription_id, effective_month, price)
+SELECT id, date_trunc('month', start_date), price FROM Subscriptions
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_subs_service_name_trgm ON Subscriptions USING gin (lower(service_name) gin_trgm_ops);
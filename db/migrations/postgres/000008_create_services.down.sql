@@ -0,0 +1,3 @@
+ALTER TABLE Subscriptions DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS services;
\ No newline at end of file
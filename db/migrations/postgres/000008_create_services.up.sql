@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category TEXT,
+    default_price INTEGER CHECK (default_price >= 0),
+    website TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services (lower(name));
+CREATE INDEX IF NOT EXISTS idx_services_aliases ON services USING gin (aliases);
+
+-- One service per case- and whitespace-insensitive name, named after its most common spelling.
+INSERT INTO services (name, aliases)
+SELECT DISTINCT ON (regexp_replace(lower(btrim(service_name)), '\s+', ' ', 'g')) btrim(service_name), ARRAY[regexp_replace(lower(btrim(service_name)), '\s+', ' ', 'g')]
+FROM Subscriptions
+GROUP BY btrim(service_name)
+ORDER BY regexp_replace(lower(btrim(service_name)), '\s+', ' ', 'g'), COUNT(*) DESC, btrim(service_name);
+
+ALTER TABLE Subscriptions ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services(id);
+
+UPDATE Subscriptions s
+SET service_id = sv.id, service_name = sv.name
+FROM services sv
+WHERE regexp_replace(lower(btrim(s.service_name)), '\s+', ' ', 'g') = ANY(sv.aliases);
+
+ALTER TABLE Subscriptions ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subs_service_id ON Subscriptions(service_id);
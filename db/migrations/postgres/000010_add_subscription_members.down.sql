@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_members;
+
+ALTER TABLE Subscriptions
+    DROP CONSTRAINT IF EXISTS subscriptions_split_rule_check,
+    DROP COLUMN IF EXISTS split_rule;
\ No newline at end of file
@@ -0,0 +1,19 @@
+ALTER TABLE Subscriptions
+    ADD COLUMN IF NOT EXISTS split_rule TEXT NOT NULL DEFAULT 'equal';
+
+ALTER TABLE Subscriptions
+    ADD CONSTRAINT subscriptions_split_rule_check CHECK (split_rule IN ('equal', 'fixed', 'percent'));
+
+CREATE TABLE IF NOT EXISTS subscription_members (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES Subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    share NUMERIC(12, 2) CHECK (share IS NULL OR share > 0),
+    joined_on DATE NOT NULL,
+    left_on DATE,
+    CHECK (left_on IS NULL OR left_on >= joined_on)
+);
+
+CREATE UNIQUE INDEX idx_subscription_members_open ON subscription_members(subscription_id, user_id) WHERE left_on IS NULL;
+
+CREATE INDEX idx_subscription_members_user ON subscription_members(user_id);
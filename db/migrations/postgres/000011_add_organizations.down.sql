@@ -0,0 +1,24 @@
+REVOKE ALL ON ALL TABLES IN SCHEMA public FROM subs_tenant;
+REVOKE USAGE ON SCHEMA public FROM subs_tenant;
+DROP ROLE IF EXISTS subs_tenant;
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_organization_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS idx_services_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services (lower(name));
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['subscriptions', 'subscription_pauses', 'subscription_price_changes', 'services', 'tags', 'subscription_tags', 'subscription_members']
+    LOOP
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS organization_id', t);
+    END LOOP;
+END $$;
+
+DROP TABLE IF EXISTS organizations;
\ No newline at end of file
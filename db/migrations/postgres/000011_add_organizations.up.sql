@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Existing data belongs to the default organisation.
+INSERT INTO organizations (id, name) VALUES ('00000000-0000-0000-0000-000000000001', 'default')
+ON CONFLICT DO NOTHING;
+
+-- Every tenant table gets organization_id, filled in on insert from the
+-- app.organization_id setting of the transaction. Exchange rates follow in 000018.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['subscriptions', 'subscription_pauses', 'subscription_price_changes', 'services', 'tags', 'subscription_tags', 'subscription_members']
+    LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id)', t);
+        EXECUTE format('UPDATE %I SET organization_id = ''00000000-0000-0000-0000-000000000001'' WHERE organization_id IS NULL', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN organization_id SET NOT NULL', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN organization_id SET DEFAULT NULLIF(current_setting(''app.organization_id'', true), '''')::uuid', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I (organization_id)', 'idx_' || t || '_organization', t);
+
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (organization_id = NULLIF(current_setting(''app.organization_id'', true), '''')::uuid)', t);
+    END LOOP;
+END $$;
+
+-- Names are unique per organisation.
+DROP INDEX IF EXISTS idx_services_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_name ON services (organization_id, lower(name));
+
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_organization_name_key UNIQUE (organization_id, name);
+
+-- The application switches to this role inside tenant transactions, so the
+-- policies apply even when it connects as a superuser or the table owner.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'subs_tenant') THEN
+        CREATE ROLE subs_tenant NOLOGIN;
+    END IF;
+END $$;
+
+GRANT subs_tenant TO CURRENT_USER;
+GRANT USAGE ON SCHEMA public TO subs_tenant;
+-- Tables created later grant the tenant role what it needs themselves.
+GRANT SELECT, INSERT, UPDATE, DELETE ON subscriptions, subscription_pauses, subscription_price_changes, services, tags, subscription_tags, subscription_members TO subs_tenant;
+GRANT SELECT ON organizations, exchange_rates TO subs_tenant;
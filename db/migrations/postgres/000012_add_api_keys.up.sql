@@ -0,0 +1,16 @@
+-- API keys are looked up before the organisation of a request is known, so
+-- the table has no row-level security; only the SHA-256 hex digest of each
+-- key is stored. A key is issued with e.g.
+--   INSERT INTO api_keys (organization_id, name, key_hash, scopes)
+--   VALUES ('00000000-0000-0000-0000-000000000001', 'ci', encode(sha256('<key>'), 'hex'), '{read,write}');
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
@@ -0,0 +1,4 @@
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_user_check;
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_role_check;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS user_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS role;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Keys act as a role; keys of the user role act for user_id. Keys issued
+-- before roles existed keep their unrestricted access as admins.
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'admin',
+    ADD COLUMN IF NOT EXISTS user_id UUID;
+
+ALTER TABLE api_keys ALTER COLUMN role SET DEFAULT 'user';
+
+ALTER TABLE api_keys
+    ADD CONSTRAINT api_keys_role_check CHECK (role IN ('user', 'analyst', 'admin'));
+
+ALTER TABLE api_keys
+    ADD CONSTRAINT api_keys_user_check CHECK (role <> 'user' OR user_id IS NOT NULL);
@@ -0,0 +1,36 @@
+-- Append-only history of subscription changes. Rows outlive the
+-- subscriptions they describe, so subscription_id has no foreign key.
+CREATE TABLE IF NOT EXISTS subscription_audit (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id)
+        DEFAULT NULLIF(current_setting('app.organization_id', true), '')::uuid,
+    subscription_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'status_change', 'delete')),
+    before JSONB,
+    after JSONB,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_audit_subscription ON subscription_audit(subscription_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_audit_organization ON subscription_audit(organization_id);
+
+ALTER TABLE subscription_audit ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_audit FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON subscription_audit
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::uuid);
+
+GRANT SELECT, INSERT ON subscription_audit TO subs_tenant;
+
+CREATE OR REPLACE FUNCTION subscription_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscription_audit_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON subscription_audit
+    FOR EACH STATEMENT EXECUTE FUNCTION subscription_audit_append_only();
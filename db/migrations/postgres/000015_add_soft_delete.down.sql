@@ -0,0 +1,11 @@
+-- Restore entries stay in the append-only log, so the narrower check
+-- only applies to new rows.
+ALTER TABLE subscription_audit
+    DROP CONSTRAINT IF EXISTS subscription_audit_action_check,
+    ADD CONSTRAINT subscription_audit_action_check
+        CHECK (action IN ('create', 'update', 'status_change', 'delete')) NOT VALID;
+
+DELETE FROM Subscriptions WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
+ALTER TABLE Subscriptions DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Deleted subscriptions keep their row until the retention purge removes it.
+ALTER TABLE Subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON Subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE subscription_audit
+    DROP CONSTRAINT IF EXISTS subscription_audit_action_check,
+    ADD CONSTRAINT subscription_audit_action_check
+        CHECK (action IN ('create', 'update', 'status_change', 'delete', 'restore'));
@@ -0,0 +1,3 @@
+-- Every change of a subscription bumps its version, which the API exposes
+-- as the ETag checked by If-Match.
+ALTER TABLE Subscriptions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
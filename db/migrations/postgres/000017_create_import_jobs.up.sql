@@ -0,0 +1,27 @@
+-- CSV imports run in the background; a job tracks each one and keeps its
+-- row-level error report.
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id)
+        DEFAULT NULLIF(current_setting('app.organization_id', true), '')::uuid,
+    created_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    dry_run BOOLEAN NOT NULL DEFAULT false,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    valid_rows INTEGER NOT NULL DEFAULT 0,
+    invalid_rows INTEGER NOT NULL DEFAULT 0,
+    imported_rows INTEGER NOT NULL DEFAULT 0,
+    row_errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_organization ON import_jobs(organization_id);
+
+ALTER TABLE import_jobs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE import_jobs FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON import_jobs
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::uuid);
+
+GRANT SELECT, INSERT, UPDATE ON import_jobs TO subs_tenant;
@@ -0,0 +1,11 @@
+REVOKE INSERT, UPDATE ON exchange_rates FROM subs_tenant;
+
+DROP POLICY IF EXISTS tenant_isolation ON exchange_rates;
+ALTER TABLE exchange_rates NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates DISABLE ROW LEVEL SECURITY;
+
+DELETE FROM exchange_rates WHERE organization_id <> '00000000-0000-0000-0000-000000000001';
+
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_pkey;
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (from_currency, to_currency, rate_date);
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- Exchange rates belong to an organisation like every other tenant table, so
+-- an admin can only change the rates their own sums are converted with. Each
+-- organisation starts with a copy of the rates loaded so far.
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+UPDATE exchange_rates SET organization_id = '00000000-0000-0000-0000-000000000001' WHERE organization_id IS NULL;
+
+INSERT INTO exchange_rates (organization_id, rate_date, from_currency, to_currency, rate)
+SELECT o.id, er.rate_date, er.from_currency, er.to_currency, er.rate
+FROM organizations o
+CROSS JOIN exchange_rates er
+WHERE o.id <> '00000000-0000-0000-0000-000000000001'
+  AND er.organization_id = '00000000-0000-0000-0000-000000000001';
+
+ALTER TABLE exchange_rates ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE exchange_rates ALTER COLUMN organization_id SET DEFAULT NULLIF(current_setting('app.organization_id', true), '')::uuid;
+
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (organization_id, from_currency, to_currency, rate_date);
+
+ALTER TABLE exchange_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON exchange_rates
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::uuid);
+
+GRANT SELECT, INSERT, UPDATE ON exchange_rates TO subs_tenant;
@@ -0,0 +1,15 @@
+package dto
+
+import "encoding/json"
+
+// AuditEntry is a change of a subscription. Before and After hold the
+// subscription as stored; Before is omitted for creations and After for
+// deletions.
+type AuditEntry struct {
+	Actor     string          `json:"actor" example:"jwt:0f8fad5b-d9cb-469f-a165-70867728950e"`
+	Action    string          `json:"action" example:"update"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt string          `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package dto
+
+// Batch modes.
+const (
+	// BatchAtomic writes every operation or none of them.
+	BatchAtomic = "atomic"
+	// BatchBestEffort writes each valid operation on its own.
+	BatchBestEffort = "best_effort"
+)
+
+// Batch operations.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchPatch  = "patch"
+	BatchDelete = "delete"
+)
+
+// MaxBatchOperations is the largest number of operations a batch accepts.
+const MaxBatchOperations = 1000
+
+// BatchRequest is the body of POST /subscriptions/batch. Mode defaults to
+// atomic.
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty" enums:"atomic,best_effort" example:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one write of a batch: create takes subscription, update
+// the full state in update and patch a merge patch. update, patch and delete
+// name an existing subscription by id and its ETag in if_match.
+type BatchOperation struct {
+	Op           string              `json:"op" enums:"create,update,patch,delete" example:"create"`
+	ID           string              `json:"id,omitempty" example:"a1b2c3d4-e5f6-7890-abcd-ef1234567890"`
+	IfMatch      string              `json:"if_match,omitempty" example:"\"3-9f2c1a0b\""`
+	Subscription *Subscription       `json:"subscription,omitempty"`
+	Update       *UpdateSubscription `json:"update,omitempty"`
+	Patch        *SubscriptionPatch  `json:"patch,omitempty"`
+}
+
+// BatchItemResult is the outcome of the operation at Index. ID is the
+// subscription written, including the one a create made.
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status" example:"201"`
+	Error  string `json:"error,omitempty"`
+	Reason string `json:"reason,omitempty"`
+
+	// Err is why the operation was not written, nil when it was.
+	Err error `json:"-"`
+}
+
+// BatchResponse lists the outcome of every operation in request order.
+type BatchResponse struct {
+	Mode    string            `json:"mode" example:"atomic"`
+	Applied int               `json:"applied"`
+	Failed  int               `json:"failed"`
+	Items   []BatchItemResult `json:"items"`
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SubscriptionETag is the strong entity tag of sub. Besides the version it
+// covers the status and next renewal date, which change with the date alone.
+func SubscriptionETag(sub Subscription) string {
+	sum := sha256.Sum256([]byte(derivedKey(sub)))
+
+	return `"` + strconv.FormatInt(sub.Version, 10) + "-" + hex.EncodeToString(sum[:4]) + `"`
+}
+
+// CollectionETag tags a set of subscriptions by their IDs, versions and
+// derived fields, so it changes when any of them changes or the set itself
+// does. Order is ignored.
+func CollectionETag(subs []Subscription) string {
+	keys := make([]string, 0, len(subs))
+
+	for _, sub := range subs {
+		keys = append(keys, sub.ID+":"+strconv.FormatInt(sub.Version, 10)+":"+derivedKey(sub))
+	}
+
+	slices.Sort(keys)
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, ",")))
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// derivedKey joins the fields computed from today's date.
+func derivedKey(sub Subscription) string {
+	return sub.Status + "|" + sub.NextRenewalDate
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header lists etag or
+// is "*". Weak comparison, used for If-None-Match, ignores the W/ prefix;
+// strong comparison never matches a weak tag.
+func MatchETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" {
+			return true
+		}
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,113 @@
+package dto
+
+import "testing"
+
+func TestMatchETag(t *testing.T) {
+	const etag = `"3-9f2c1a0b"`
+
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{"same tag", `"3-9f2c1a0b"`, false, true},
+		{"other tag", `"2-9f2c1a0b"`, false, false},
+		{"any", `*`, false, true},
+		{"any in a list", `"1-00000000", *`, false, true},
+		{"in a list", `"1-00000000", "3-9f2c1a0b"`, false, true},
+		{"in a list without spaces", `"1-00000000","3-9f2c1a0b"`, false, true},
+		{"not in a list", `"1-00000000", "2-00000000"`, false, false},
+		{"weak tag, strong comparison", `W/"3-9f2c1a0b"`, false, false},
+		{"weak tag, weak comparison", `W/"3-9f2c1a0b"`, true, true},
+		{"weak tag in a list, weak comparison", `"1-00000000", W/"3-9f2c1a0b"`, true, true},
+		{"strong tag, weak comparison", `"3-9f2c1a0b"`, true, true},
+		{"unquoted", `3-9f2c1a0b`, false, false},
+		{"empty", ``, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchETag(tt.header, etag, tt.weak); got != tt.want {
+				t.Errorf("MatchETag(%q, %q, %v) = %v, want %v", tt.header, etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectionETagIgnoresOrder(t *testing.T) {
+	a := Subscription{ID: "a", Version: 1, Status: "active"}
+	b := Subscription{ID: "b", Version: 4, Status: "trial", NextRenewalDate: "2025-08-01"}
+	c := Subscription{ID: "c", Version: 2, Status: "paused"}
+
+	want := CollectionETag([]Subscription{a, b, c})
+
+	for _, order := range [][]Subscription{{c, b, a}, {b, a, c}, {a, c, b}} {
+		if got := CollectionETag(order); got != want {
+			t.Errorf("CollectionETag(%s, %s, %s) = %s, want %s", order[0].ID, order[1].ID, order[2].ID, got, want)
+		}
+	}
+}
+
+func TestCollectionETagChanges(t *testing.T) {
+	a := Subscription{ID: "a", Version: 1, Status: "active", NextRenewalDate: "2025-08-01"}
+	b := Subscription{ID: "b", Version: 4, Status: "trial"}
+
+	base := CollectionETag([]Subscription{a, b})
+
+	bumped := a
+	bumped.Version++
+
+	expired := a
+	expired.Status = "expired"
+
+	renewed := a
+	renewed.NextRenewalDate = "2025-09-01"
+
+	tests := []struct {
+		name string
+		subs []Subscription
+	}{
+		{"version", []Subscription{bumped, b}},
+		{"status", []Subscription{expired, b}},
+		{"next renewal", []Subscription{renewed, b}},
+		{"fewer", []Subscription{a}},
+		{"none", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CollectionETag(tt.subs); got == base {
+				t.Errorf("CollectionETag did not change with %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestSubscriptionETag(t *testing.T) {
+	sub := Subscription{ID: "a", Version: 3, Status: "trial", NextRenewalDate: "2025-08-01"}
+	etag := SubscriptionETag(sub)
+
+	if !MatchETag(etag, etag, false) {
+		t.Fatalf("SubscriptionETag %s does not match itself strongly", etag)
+	}
+
+	if again := SubscriptionETag(sub); again != etag {
+		t.Errorf("SubscriptionETag is not stable: %s then %s", etag, again)
+	}
+
+	active := sub
+	active.Status = "active"
+
+	renewed := sub
+	renewed.NextRenewalDate = "2025-09-01"
+
+	bumped := sub
+	bumped.Version++
+
+	for name, changed := range map[string]Subscription{"status": active, "next renewal": renewed, "version": bumped} {
+		if SubscriptionETag(changed) == etag {
+			t.Errorf("SubscriptionETag did not change with %s", name)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package dto
+
+type ExchangeRate struct {
+	Date string  `json:"date" example:"2025-07-01"`
+	From string  `json:"from" example:"USD"`
+	To   string  `json:"to" example:"RUB"`
+	Rate float64 `json:"rate" example:"78.5"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+// ImportOptions tell how to read a CSV of subscriptions. Mapping names the
+// CSV column of each subscription field, e.g. {"service_name": "Service"};
+// unmapped fields are read from the column named after the field.
+// DateFormat spells the date layout with YYYY, MM and DD, e.g. DD.MM.YYYY;
+// without it dates are YYYY-MM-DD or MM-YYYY. A format without DD reads an
+// end date as the last day of its month. Currency applies to rows without
+// one.
+type ImportOptions struct {
+	Mapping    map[string]string `json:"mapping,omitempty"`
+	DateFormat string            `json:"date_format,omitempty" example:"DD.MM.YYYY"`
+	Currency   string            `json:"currency,omitempty" example:"EUR"`
+	DryRun     bool              `json:"dry_run,omitempty"`
+}
+
+// ImportRowError is why the CSV row on Line was rejected. Line 1 is the header.
+type ImportRowError struct {
+	Line  int    `json:"line" example:"7"`
+	Error string `json:"error" example:"invalid data: unknown ISO 4217 currency \"EURO\""`
+}
+
+// ImportJob is the status of a CSV import. RowErrors lists at most the first
+// 1000 rejected rows; InvalidRows counts them all.
+type ImportJob struct {
+	ID           string           `json:"id"`
+	Status       string           `json:"status" enums:"pending,running,completed,failed"`
+	DryRun       bool             `json:"dry_run"`
+	TotalRows    int              `json:"total_rows"`
+	ValidRows    int              `json:"valid_rows"`
+	InvalidRows  int              `json:"invalid_rows"`
+	ImportedRows int              `json:"imported_rows"`
+	RowErrors    []ImportRowError `json:"row_errors"`
+	Error        string           `json:"error,omitempty"`
+	CreatedAt    string           `json:"created_at"`
+	FinishedAt   string           `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type SubscriptionMember struct {
+	UserID   string   `json:"user_id"`
+	Share    *float64 `json:"share,omitempty" example:"25"`
+	JoinedOn string   `json:"joined_on" example:"2025-07-01"`
+	LeftOn   string   `json:"left_on,omitempty" example:"2026-01-01"`
+}
+
+type AddSubscriptionMemberRequest struct {
+	UserID        string   `json:"user_id"`
+	Share         *float64 `json:"share,omitempty" example:"25"`
+	EffectiveDate string   `json:"effective_date,omitempty" example:"2025-07-01"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+type FilterSubscriptionsRequest struct {
+	Subscription
+	Q            string `json:"q" example:"netflix"`
+	Limit        int    `json:"limit" example:"100"`
+	Cursor       string `json:"cursor"`
+	Sort         string `json:"sort" enums:"price,start_date,service_name,relevance"`
+	Order        string `json:"order" enums:"asc,desc"`
+	IncludeTotal bool   `json:"include_total"`
+
+	// MonthlyCostMin matches subscriptions costing at least this much per
+	// month once the billing period is normalised. Price is the raw price.
+	MonthlyCostMin int `json:"monthly_cost_min" example:"500"`
+
+	// IncludeDeleted also returns deleted subscriptions. Admins only.
+	IncludeDeleted bool `json:"include_deleted"`
+}
+
+type SubscriptionPage struct {
+	Items      []Subscription `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Total      *int           `json:"total,omitempty"`
+}
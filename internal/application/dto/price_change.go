@@ -0,0 +1,7 @@
+package dto
+
+type PriceChange struct {
+	EffectiveMonth string `json:"effective_month" example:"2025-09-01"`
+	Price          int    `json:"price"`
+	CreatedAt      string `json:"created_at"`
+}
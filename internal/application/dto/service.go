@@ -0,0 +1,15 @@
+package dto
+
+type Service struct {
+	ID           string   `json:"id,omitempty"`
+	Name         string   `json:"name" example:"Yandex Plus"`
+	Aliases      []string `json:"aliases" example:"яндекс плюс"`
+	Category     string   `json:"category,omitempty" example:"streaming"`
+	DefaultPrice *int     `json:"default_price,omitempty" example:"399"`
+	Website      string   `json:"website,omitempty" example:"https://plus.yandex.ru"`
+	CreatedAt    string   `json:"created_at,omitempty"`
+}
+
+type MergeServicesRequest struct {
+	SourceID string `json:"source_id"`
+}
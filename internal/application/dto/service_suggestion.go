@@ -0,0 +1,6 @@
+package dto
+
+type ServiceSuggestion struct {
+	ServiceName   string `json:"service_name"`
+	Subscriptions int    `json:"subscriptions"`
+}
@@ -1,19 +1,89 @@
 package dto
 
 type Subscription struct {
-	ServiceName string `json:"service_name"`
-	Price       int    `json:"price"`
-	UserID      string `json:"user_id"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date"`
+	ID              string   `json:"id,omitempty"`
+	ServiceID       string   `json:"service_id,omitempty"`
+	ServiceName     string   `json:"service_name"`
+	Price           int      `json:"price"`
+	Currency        string   `json:"currency,omitempty" example:"RUB"`
+	BillingPeriod   string   `json:"billing_period,omitempty" enums:"week,month,quarter,year"`
+	BillingInterval int      `json:"billing_interval,omitempty"`
+	MonthlyCost     float64  `json:"monthly_cost,omitempty"`
+	AnnualCost      float64  `json:"annual_cost,omitempty"`
+	UserID          string   `json:"user_id"`
+	StartDate       string   `json:"start_date" example:"2025-07-17"`
+	EndDate         string   `json:"end_date" example:"2026-07-16"`
+	NextRenewalDate string   `json:"next_renewal_date,omitempty"`
+	Status          string   `json:"status,omitempty" enums:"trial,active,paused,cancelled,expired"`
+	TrialEndDate    string   `json:"trial_end_date,omitempty" example:"2025-08-17"`
+	StatusChangedAt string   `json:"status_changed_at,omitempty"`
+	CancelledAt     string   `json:"cancelled_at,omitempty"`
+	DeletedAt       string   `json:"deleted_at,omitempty"`
+	SplitRule       string   `json:"split_rule,omitempty" enums:"equal,fixed,percent"`
+	Relevance       float64  `json:"relevance,omitempty"`
+	Tags            []string `json:"tags,omitempty" example:"work,reimbursable"`
+	Version         int64    `json:"version,omitempty"`
 }
+
+type CancelSubscriptionRequest struct {
+	AtPeriodEnd *bool `json:"at_period_end" example:"true"`
+}
+
 type SumSubscriptionsRequest struct {
-	UserID      string `json:"user_id"`
-	ServiceName string `json:"service_name"`
-	StartPeriod string `json:"start_date"`
-	EndPeriod   string `json:"end_date"`
+	UserID         string   `json:"user_id"`
+	ServiceName    string   `json:"service_name"`
+	StartPeriod    string   `json:"start_date"`
+	EndPeriod      string   `json:"end_date"`
+	TargetCurrency string   `json:"target_currency" example:"RUB"`
+	GroupBy        []string `json:"group_by,omitempty" example:"tag"`
 }
 
 type SumSubscriptionsResponse struct {
-	Total int `json:"total"`
+	Total    float64                     `json:"total"`
+	Currency string                      `json:"currency"`
+	Items    []SubscriptionCost          `json:"items"`
+	Groups   []AggregateSubscriptionsRow `json:"groups,omitempty"`
+}
+
+type SubscriptionCost struct {
+	SubscriptionID  string  `json:"subscription_id"`
+	ServiceName     string  `json:"service_name"`
+	UserID          string  `json:"user_id"`
+	Price           int     `json:"price"`
+	Currency        string  `json:"currency"`
+	BillingPeriod   string  `json:"billing_period"`
+	BillingInterval int     `json:"billing_interval"`
+	MonthlyCost     float64 `json:"monthly_cost"`
+	Months          int     `json:"months"`
+	Cost            float64 `json:"cost"`
+}
+
+type MonthlyCost struct {
+	Month       string  `json:"month"`
+	Total       float64 `json:"total"`
+	Currency    string  `json:"currency"`
+	ActiveCount int     `json:"active_count"`
+}
+
+type AggregateSubscriptionsRequest struct {
+	GroupBy        []string `json:"group_by"`
+	UserID         string   `json:"user_id"`
+	ServiceName    string   `json:"service_name"`
+	StartPeriod    string   `json:"start_date"`
+	EndPeriod      string   `json:"end_date"`
+	TargetCurrency string   `json:"target_currency" example:"RUB"`
+	SortBy         string   `json:"sort_by"`
+	Order          string   `json:"order"`
+	Limit          int      `json:"limit"`
+}
+
+type AggregateSubscriptionsRow struct {
+	ServiceName string  `json:"service_name,omitempty"`
+	UserID      string  `json:"user_id,omitempty"`
+	Month       string  `json:"month,omitempty"`
+	Tag         string  `json:"tag,omitempty"`
+	Total       float64 `json:"total"`
+	Count       int     `json:"count"`
+	Average     float64 `json:"average"`
+	Currency    string  `json:"currency"`
 }
@@ -0,0 +1,123 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SubscriptionQuery is the typed form of the GET /subscriptions query string.
+type SubscriptionQuery struct {
+	UserID        string
+	ServiceNameIn []string
+	Tags          []string
+	Q             string
+	Currency      string
+	BillingPeriod string
+	PriceMin      *int
+	PriceMax      *int
+	ActiveOn      string
+	StartedAfter  string
+	EndedBefore   string
+	Limit         int
+	Cursor        string
+	Sort          string
+	Order         string
+	IncludeTotal  bool
+
+	// IncludeDeleted also returns deleted subscriptions. Admins only.
+	IncludeDeleted bool
+}
+
+// ParseSubscriptionQuery reads the search operators from values. service_name_in
+// and tags may be repeated or comma-separated; unknown parameters are rejected.
+func ParseSubscriptionQuery(values url.Values) (SubscriptionQuery, error) {
+	var query SubscriptionQuery
+
+	for key, vals := range values {
+		value := vals[len(vals)-1]
+
+		var err error
+
+		switch key {
+		case "user_id":
+			query.UserID = value
+		case "service_name_in":
+			query.ServiceNameIn = splitListParam(vals)
+		case "tags":
+			query.Tags = splitListParam(vals)
+		case "q":
+			query.Q = value
+		case "currency":
+			query.Currency = value
+		case "billing_period":
+			query.BillingPeriod = value
+		case "price_min":
+			query.PriceMin, err = parseIntParam(key, value)
+		case "price_max":
+			query.PriceMax, err = parseIntParam(key, value)
+		case "active_on":
+			query.ActiveOn = value
+		case "started_after":
+			query.StartedAfter = value
+		case "ended_before":
+			query.EndedBefore = value
+		case "limit":
+			var limit *int
+
+			limit, err = parseIntParam(key, value)
+			if limit != nil {
+				query.Limit = *limit
+			}
+		case "cursor":
+			query.Cursor = value
+		case "sort":
+			query.Sort = value
+		case "order":
+			query.Order = value
+		case "include_total":
+			query.IncludeTotal, err = strconv.ParseBool(value)
+			if err != nil {
+				err = errors.New("invalid data: include_total must be true or false")
+			}
+		case "include_deleted":
+			query.IncludeDeleted, err = strconv.ParseBool(value)
+			if err != nil {
+				err = errors.New("invalid data: include_deleted must be true or false")
+			}
+		default:
+			err = fmt.Errorf("invalid data: unknown query parameter %q", key)
+		}
+
+		if err != nil {
+			return SubscriptionQuery{}, err
+		}
+	}
+
+	return query, nil
+}
+
+func splitListParam(vals []string) []string {
+	var items []string
+
+	for _, v := range vals {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+func parseIntParam(key, value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %s must be an integer", key)
+	}
+
+	return &n, nil
+}
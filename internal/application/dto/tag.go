@@ -0,0 +1,6 @@
+package dto
+
+type Tag struct {
+	Name          string `json:"name"`
+	Subscriptions int    `json:"subscriptions"`
+}
@@ -1,8 +1,91 @@
 package dto
 
+import "encoding/json"
+
+// UpdateSubscription is the full state PUT /subscriptions/{id} replaces a
+// subscription with. Omitted fields take their defaults: no end_date makes
+// the subscription open-ended, price 0 makes it free and no tags removes them.
 type UpdateSubscription struct {
-	ServiceName string `json:"service_name"`
-	Price       int    `json:"price"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date"`
+	ServiceID          string   `json:"service_id"`
+	ServiceName        string   `json:"service_name"`
+	Price              int      `json:"price"`
+	PriceEffectiveDate string   `json:"price_effective_date" example:"2025-09-01"`
+	Currency           string   `json:"currency"`
+	BillingPeriod      string   `json:"billing_period" enums:"week,month,quarter,year"`
+	BillingInterval    int      `json:"billing_interval"`
+	StartDate          string   `json:"start_date"`
+	EndDate            string   `json:"end_date"`
+	SplitRule          string   `json:"split_rule" enums:"equal,fixed,percent"`
+	Tags               []string `json:"tags" example:"work"`
+}
+
+// Nullable is a member of a JSON merge patch: Set when present in the
+// document, and Null as well when its value is null.
+type Nullable[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+
+	if string(data) == "null" {
+		n.Null = true
+
+		return nil
+	}
+
+	return json.Unmarshal(data, &n.Value)
+}
+
+// apply copies a present member into field; null resets it to the zero value.
+func (n Nullable[T]) apply(field *T) {
+	if !n.Set {
+		return
+	}
+
+	var value T
+	if !n.Null {
+		value = n.Value
+	}
+
+	*field = value
+}
+
+// SubscriptionPatch is a JSON merge patch (RFC 7396) of a subscription.
+// Absent members are left unchanged and null members clear the field, which
+// then takes its PUT default.
+type SubscriptionPatch struct {
+	ServiceID          Nullable[string]   `json:"service_id" swaggertype:"string"`
+	ServiceName        Nullable[string]   `json:"service_name" swaggertype:"string"`
+	Price              Nullable[int]      `json:"price" swaggertype:"integer"`
+	PriceEffectiveDate Nullable[string]   `json:"price_effective_date" swaggertype:"string" example:"2025-09-01"`
+	Currency           Nullable[string]   `json:"currency" swaggertype:"string"`
+	BillingPeriod      Nullable[string]   `json:"billing_period" swaggertype:"string" enums:"week,month,quarter,year"`
+	BillingInterval    Nullable[int]      `json:"billing_interval" swaggertype:"integer"`
+	StartDate          Nullable[string]   `json:"start_date" swaggertype:"string"`
+	EndDate            Nullable[string]   `json:"end_date" swaggertype:"string"`
+	SplitRule          Nullable[string]   `json:"split_rule" swaggertype:"string" enums:"equal,fixed,percent"`
+	Tags               Nullable[[]string] `json:"tags" swaggertype:"array,string" example:"work"`
+}
+
+// Apply merges the patch into sub. Naming the service without service_id
+// drops the service_id sub had, so the name is resolved again.
+func (p SubscriptionPatch) Apply(sub *UpdateSubscription) {
+	if p.ServiceName.Set && !p.ServiceID.Set {
+		sub.ServiceID = ""
+	}
+
+	p.ServiceID.apply(&sub.ServiceID)
+	p.ServiceName.apply(&sub.ServiceName)
+	p.Price.apply(&sub.Price)
+	p.PriceEffectiveDate.apply(&sub.PriceEffectiveDate)
+	p.Currency.apply(&sub.Currency)
+	p.BillingPeriod.apply(&sub.BillingPeriod)
+	p.BillingInterval.apply(&sub.BillingInterval)
+	p.StartDate.apply(&sub.StartDate)
+	p.EndDate.apply(&sub.EndDate)
+	p.SplitRule.apply(&sub.SplitRule)
+	p.Tags.apply(&sub.Tags)
 }
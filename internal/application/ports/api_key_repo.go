@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+type APIKeyRepo interface {
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error)
+	TouchAPIKey(ctx context.Context, id string) error
+}
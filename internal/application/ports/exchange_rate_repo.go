@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+type ExchangeRateRepo interface {
+	UpsertExchangeRates(ctx context.Context, rates []entities.ExchangeRate) error
+	GetExchangeRates(ctx context.Context, from, to entities.Currency) ([]entities.ExchangeRate, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
+
+type ExchangeRateService interface {
+	SaveExchangeRates(ctx context.Context, rates []dto.ExchangeRate) (int, error)
+	ImportExchangeRatesCSV(ctx context.Context, r io.Reader) (int, error)
+	GetExchangeRates(ctx context.Context, from, to string) ([]dto.ExchangeRate, error)
+}
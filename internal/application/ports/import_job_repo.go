@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+type ImportJobRepo interface {
+	CreateImportJob(ctx context.Context, job entities.ImportJob) (entities.ImportJob, error)
+	GetImportJob(ctx context.Context, id string) (entities.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job entities.ImportJob) error
+	FailUnfinishedImportJobs(ctx context.Context, reason string) (int64, error)
+}
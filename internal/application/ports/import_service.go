@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+	"io"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
+
+type ImportService interface {
+	ImportSubscriptionsCSV(ctx context.Context, options dto.ImportOptions, r io.Reader) (dto.ImportJob, error)
+	GetImportJob(ctx context.Context, id string) (dto.ImportJob, error)
+}
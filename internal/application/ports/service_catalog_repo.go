@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+type ServiceCatalogRepo interface {
+	CreateService(ctx context.Context, service entities.Service) (string, error)
+	GetServiceByID(ctx context.Context, id string) (entities.Service, error)
+	GetServices(ctx context.Context, category string) ([]entities.Service, error)
+	FindServicesByAliases(ctx context.Context, aliases []string) ([]entities.Service, error)
+	UpdateService(ctx context.Context, service entities.Service) error
+	DeleteService(ctx context.Context, id string) error
+	MergeServices(ctx context.Context, targetID, sourceID string) error
+}
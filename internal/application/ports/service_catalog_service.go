@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
+
+type ServiceCatalogService interface {
+	CreateService(ctx context.Context, service dto.Service) (string, error)
+	GetServiceByID(ctx context.Context, id string) (dto.Service, error)
+	GetServices(ctx context.Context, category string) ([]dto.Service, error)
+	UpdateService(ctx context.Context, service dto.Service, id string) error
+	DeleteService(ctx context.Context, id string) error
+	MergeServices(ctx context.Context, targetID, sourceID string) (dto.Service, error)
+}
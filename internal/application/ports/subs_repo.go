@@ -1,16 +1,35 @@
 package ports
 
 import (
+	"context"
 	"time"
 
 	"github.com/agl/online_subs/internal/domain/entities"
 )
 
 type SubscriptionRepo interface {
-	CreateSubscription(subscription entities.Subscription) error
-	GetSubscriptionByUserUUID(userUUID string) (entities.Subscription, error)
-	GetSubscriptionFiltered(subscription entities.Subscription) ([]entities.Subscription, error)
-	UpdateSubscriptionByUserUUID(subscription entities.Subscription) error
-	DeleteSubscriptionByUserUUID(userUUID string) error
-	SumSubscriptions(userID, serviceName string, startPeriod *time.Time, endPeriod *time.Time) (int, error)
+	CreateSubscription(ctx context.Context, subscription entities.Subscription) (string, error)
+	GetSubscriptionByID(ctx context.Context, id string, includeDeleted bool) (entities.Subscription, error)
+	GetSubscriptionsByUserUUID(ctx context.Context, userUUID string) ([]entities.Subscription, error)
+	GetSubscriptionFiltered(ctx context.Context, filter entities.SubscriptionFilter, page entities.PageRequest) ([]entities.Subscription, error)
+	CountSubscriptionsFiltered(ctx context.Context, filter entities.SubscriptionFilter) (int, error)
+	StreamSubscriptions(ctx context.Context, filter entities.SubscriptionFilter, each func(entities.Subscription) error) error
+	SuggestServiceNames(ctx context.Context, prefixes []string, limit uint64) ([]entities.ServiceSuggestion, error)
+	GetTags(ctx context.Context) ([]entities.Tag, error)
+	ReplaceSubscriptions(ctx context.Context, subscriptions []entities.Subscription, expected entities.Versions) error
+	ApplySubscriptionBatch(ctx context.Context, batch entities.SubscriptionBatch) ([]string, error)
+	ChangeSubscriptionStatus(ctx context.Context, change entities.StatusChange) error
+	GetPriceChanges(ctx context.Context, subscriptionID string) ([]entities.PriceChange, error)
+	GetSubscriptionAudit(ctx context.Context, subscriptionID string) ([]entities.AuditEntry, error)
+	GetSubscriptionMembers(ctx context.Context, subscriptionID string) ([]entities.SubscriptionMember, error)
+	AddSubscriptionMember(ctx context.Context, member entities.SubscriptionMember) error
+	RemoveSubscriptionMember(ctx context.Context, subscriptionID, userID string, leftOn time.Time) error
+	DeleteSubscriptionByID(ctx context.Context, id string, expected entities.Versions) error
+	DeleteSubscriptionByUserUUID(ctx context.Context, userUUID string, expected entities.Versions) error
+	RestoreSubscription(ctx context.Context, id string) error
+	PurgeDeletedSubscriptions(ctx context.Context, cutoff time.Time) (int64, error)
+	SumSubscriptions(ctx context.Context, filter entities.CostFilter) ([]entities.SubscriptionCost, error)
+	MonthlySubscriptionCosts(ctx context.Context, filter entities.CostFilter) ([]entities.MonthlyCost, error)
+	AggregateSubscriptions(ctx context.Context, spec entities.AggregationSpec) ([]entities.AggregationRow, error)
+	MissingExchangeRates(ctx context.Context, filter entities.CostFilter) ([]entities.MissingExchangeRate, error)
 }
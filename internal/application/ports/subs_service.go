@@ -1,12 +1,37 @@
 package ports
 
-import "github.com/agl/online_subs/internal/application/dto"
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
 
 type SubscriptionService interface {
-	CreateSubscription(subscripption dto.Subscription) error
-	GetSubscriptionByUserUUID(userUUID string) (dto.Subscription, error)
-	GetSubscriptionFiltered(subscription dto.Subscription) ([]dto.Subscription, error)
-	UpdateSubscriptionByUserUUID(subscripption dto.UpdateSubscription, userUUID string) error
-	DeleteSubscriptionByUserUUID(userUUID string) error
-	SumSubscriptions(req dto.SumSubscriptionsRequest) (int, error)
+	CreateSubscription(ctx context.Context, subscripption dto.Subscription) (string, error)
+	GetSubscriptionByID(ctx context.Context, id string, includeDeleted bool) (dto.Subscription, error)
+	GetSubscriptionsByUserUUID(ctx context.Context, userUUID string) ([]dto.Subscription, error)
+	GetSubscriptionFiltered(ctx context.Context, req dto.FilterSubscriptionsRequest) (dto.SubscriptionPage, error)
+	SearchSubscriptions(ctx context.Context, query dto.SubscriptionQuery) (dto.SubscriptionPage, error)
+	ExportSubscriptions(ctx context.Context, query dto.SubscriptionQuery, each func(dto.Subscription) error) error
+	SuggestServiceNames(ctx context.Context, prefix string, limit int) ([]dto.ServiceSuggestion, error)
+	GetTags(ctx context.Context) ([]dto.Tag, error)
+	UpdateSubscriptionByID(ctx context.Context, subscripption dto.UpdateSubscription, id, ifMatch string) error
+	PatchSubscriptionByID(ctx context.Context, patch dto.SubscriptionPatch, id, ifMatch string) error
+	PatchSubscriptionsByUserUUID(ctx context.Context, patch dto.SubscriptionPatch, userUUID, ifMatch string) error
+	ReplaceSubscriptionsByUserUUID(ctx context.Context, update dto.UpdateSubscription, userUUID, ifMatch string) error
+	BatchSubscriptions(ctx context.Context, req dto.BatchRequest) (dto.BatchResponse, error)
+	GetPriceHistory(ctx context.Context, id string) ([]dto.PriceChange, error)
+	GetSubscriptionHistory(ctx context.Context, id string) ([]dto.AuditEntry, error)
+	GetSubscriptionMembers(ctx context.Context, id string) ([]dto.SubscriptionMember, error)
+	AddSubscriptionMember(ctx context.Context, id string, req dto.AddSubscriptionMemberRequest) (dto.SubscriptionMember, error)
+	RemoveSubscriptionMember(ctx context.Context, id, userID, effectiveDate string) error
+	PauseSubscription(ctx context.Context, id string) (dto.Subscription, error)
+	ResumeSubscription(ctx context.Context, id string) (dto.Subscription, error)
+	CancelSubscription(ctx context.Context, id string, req dto.CancelSubscriptionRequest) (dto.Subscription, error)
+	DeleteSubscriptionByID(ctx context.Context, id, ifMatch string) error
+	DeleteSubscriptionByUserUUID(ctx context.Context, userUUID, ifMatch string) error
+	RestoreSubscription(ctx context.Context, id string) (dto.Subscription, error)
+	SumSubscriptions(ctx context.Context, req dto.SumSubscriptionsRequest) (dto.SumSubscriptionsResponse, error)
+	MonthlySubscriptionCosts(ctx context.Context, req dto.SumSubscriptionsRequest) ([]dto.MonthlyCost, error)
+	AggregateSubscriptions(ctx context.Context, req dto.AggregateSubscriptionsRequest) ([]dto.AggregateSubscriptionsRow, error)
 }
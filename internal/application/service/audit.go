@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// GetSubscriptionHistory returns the audit log of a subscription, oldest
+// first. The log outlives the subscription, so access is decided by the
+// owner it records.
+func (s *SubscriptionService) GetSubscriptionHistory(ctx context.Context, id string) ([]dto.AuditEntry, error) {
+	logger.Log.Info("GetSubscriptionHistory called", "id", id)
+
+	entries, err := s.repo.GetSubscriptionAudit(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription history", "error", err)
+
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		if _, err := s.ownedSubscription(ctx, id); err != nil {
+			return nil, err
+		}
+	} else if err := authorizeOwner(ctx, entries[len(entries)-1].UserID); err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.AuditEntry, 0, len(entries))
+
+	for _, entry := range entries {
+		result = append(result, dto.AuditEntry{
+			Actor:     entry.Actor,
+			Action:    string(entry.Action),
+			Before:    entry.Before,
+			After:     entry.After,
+			RequestID: entry.RequestID,
+			CreatedAt: entry.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	logger.Log.Info("Subscription history fetched successfully", "id", id, "count", len(result))
+
+	return result, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// batchWrite is a batch operation validated into the write it makes.
+type batchWrite struct {
+	op           string
+	subscription entities.Subscription
+
+	// version is the version an updated or deleted subscription was read at.
+	version int64
+}
+
+// BatchSubscriptions validates every operation of req and writes them. In
+// atomic mode nothing is written unless all of them are valid, and then in
+// one transaction; in best-effort mode the creations are written together and
+// every other operation in a transaction of its own.
+func (s *SubscriptionService) BatchSubscriptions(ctx context.Context, req dto.BatchRequest) (dto.BatchResponse, error) {
+	logger.Log.Info("BatchSubscriptions called", "mode", req.Mode, "count", len(req.Operations))
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAdmin); err != nil {
+		return dto.BatchResponse{}, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = dto.BatchAtomic
+	}
+
+	if mode != dto.BatchAtomic && mode != dto.BatchBestEffort {
+		return dto.BatchResponse{}, fmt.Errorf("invalid data: unknown batch mode %q", req.Mode)
+	}
+
+	if len(req.Operations) == 0 || len(req.Operations) > dto.MaxBatchOperations {
+		return dto.BatchResponse{}, fmt.Errorf("invalid data: a batch takes 1 to %d operations", dto.MaxBatchOperations)
+	}
+
+	items := make([]dto.BatchItemResult, len(req.Operations))
+	writes := make([]batchWrite, len(req.Operations))
+	seen := make(map[string]int)
+
+	for i, op := range req.Operations {
+		items[i] = dto.BatchItemResult{Index: i, Op: op.Op, ID: op.ID}
+
+		if op.ID != "" {
+			if first, ok := seen[op.ID]; ok {
+				items[i].Err = fmt.Errorf("invalid data: subscription %s is already changed by operation %d", op.ID, first)
+
+				continue
+			}
+
+			seen[op.ID] = i
+		}
+
+		writes[i], items[i].Err = s.batchWrite(ctx, op)
+	}
+
+	if mode == dto.BatchAtomic {
+		s.applyAtomic(ctx, writes, items)
+	} else {
+		s.applyEach(ctx, writes, items)
+	}
+
+	resp := dto.BatchResponse{Mode: mode, Items: items}
+
+	for _, item := range items {
+		if item.Err != nil {
+			resp.Failed++
+		} else {
+			resp.Applied++
+		}
+	}
+
+	logger.Log.Info("Subscription batch processed", "mode", mode, "applied", resp.Applied, "failed", resp.Failed)
+
+	return resp, nil
+}
+
+// batchWrite validates op the way the single-subscription endpoint for it
+// does, policy checks included.
+func (s *SubscriptionService) batchWrite(ctx context.Context, op dto.BatchOperation) (batchWrite, error) {
+	if op.Op == dto.BatchCreate {
+		if op.Subscription == nil {
+			return batchWrite{}, errors.New("invalid data: create takes a subscription")
+		}
+
+		sub, err := s.newSubscription(ctx, *op.Subscription)
+
+		return batchWrite{op: op.Op, subscription: sub}, err
+	}
+
+	if op.Op != dto.BatchUpdate && op.Op != dto.BatchPatch && op.Op != dto.BatchDelete {
+		return batchWrite{}, fmt.Errorf("invalid data: unknown batch operation %q", op.Op)
+	}
+
+	if op.ID == "" {
+		return batchWrite{}, fmt.Errorf("invalid data: %s takes an id", op.Op)
+	}
+
+	// Like the single-subscription endpoints, writes to existing
+	// subscriptions must name the version they were read at.
+	if strings.TrimSpace(op.IfMatch) == "" {
+		return batchWrite{}, errormsgs.PreconditionRequired
+	}
+
+	current, err := s.ownedSubscription(ctx, op.ID)
+	if err != nil {
+		return batchWrite{}, err
+	}
+
+	if _, err := expectVersions(op.IfMatch, dto.SubscriptionETag(toSubscriptionDTO(current)), current); err != nil {
+		return batchWrite{}, err
+	}
+
+	write := batchWrite{op: op.Op, subscription: current, version: current.Version}
+
+	switch op.Op {
+	case dto.BatchUpdate:
+		if op.Update == nil {
+			return batchWrite{}, errors.New("invalid data: update takes the full subscription in update")
+		}
+
+		write.subscription, err = s.replacement(ctx, current, *op.Update)
+	case dto.BatchPatch:
+		if op.Patch == nil {
+			return batchWrite{}, errors.New("invalid data: patch takes a merge patch in patch")
+		}
+
+		update := toUpdateSubscription(current)
+		op.Patch.Apply(&update)
+
+		write.subscription, err = s.replacement(ctx, current, update)
+	}
+
+	return write, err
+}
+
+// applyAtomic writes all operations in one transaction when every one of them
+// is valid. Otherwise the valid ones are marked NotApplied.
+func (s *SubscriptionService) applyAtomic(ctx context.Context, writes []batchWrite, items []dto.BatchItemResult) {
+	indexes := make([]int, 0, len(items))
+
+	for i, item := range items {
+		if item.Err == nil {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(indexes) < len(items) {
+		for _, i := range indexes {
+			items[i].Err = errormsgs.NotApplied
+		}
+
+		return
+	}
+
+	if err := s.applyWrites(ctx, writes, items, indexes); err != nil {
+		logger.Log.Error("Failed to apply subscription batch", "error", err)
+
+		for i := range items {
+			items[i].Err = err
+		}
+	}
+}
+
+// applyEach writes the valid creations together, falling back to one at a
+// time to tell which failed, and every other valid operation on its own.
+func (s *SubscriptionService) applyEach(ctx context.Context, writes []batchWrite, items []dto.BatchItemResult) {
+	var creates, others []int
+
+	for i, item := range items {
+		switch {
+		case item.Err != nil:
+		case writes[i].op == dto.BatchCreate:
+			creates = append(creates, i)
+		default:
+			others = append(others, i)
+		}
+	}
+
+	if len(creates) > 0 {
+		if err := s.applyWrites(ctx, writes, items, creates); err != nil {
+			logger.Log.Error("Failed to create batch subscriptions together, retrying one by one", "error", err)
+
+			others = append(creates, others...)
+		}
+	}
+
+	for _, i := range others {
+		items[i].Err = s.applyWrites(ctx, writes, items, []int{i})
+	}
+}
+
+// applyWrites commits the writes at indexes in one transaction, recording the
+// IDs of created subscriptions in items.
+func (s *SubscriptionService) applyWrites(ctx context.Context, writes []batchWrite, items []dto.BatchItemResult, indexes []int) error {
+	batch := entities.SubscriptionBatch{Expected: make(entities.Versions)}
+	created := make([]int, 0, len(indexes))
+
+	for _, i := range indexes {
+		write := writes[i]
+
+		switch write.op {
+		case dto.BatchCreate:
+			batch.Create = append(batch.Create, write.subscription)
+			created = append(created, i)
+
+			continue
+		case dto.BatchDelete:
+			batch.Delete = append(batch.Delete, write.subscription.ID)
+		default:
+			batch.Replace = append(batch.Replace, write.subscription)
+		}
+
+		batch.Expected[write.subscription.ID] = write.version
+	}
+
+	ids, err := s.repo.ApplySubscriptionBatch(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	for n, i := range created {
+		items[i].ID = ids[n]
+	}
+
+	return nil
+}
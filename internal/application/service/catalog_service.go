@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+type CatalogService struct {
+	repo ports.ServiceCatalogRepo
+}
+
+func NewCatalogService(repo ports.ServiceCatalogRepo) *CatalogService {
+	return &CatalogService{
+		repo: repo,
+	}
+}
+
+// normalizeAlias lower-cases a service name and collapses its whitespace so
+// that "Yandex Plus" and " yandex  plus" resolve to the same service.
+func normalizeAlias(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// resolveService finds the catalog entry for a free-text service name through
+// its aliases, creating a new entry for names not seen before.
+func resolveService(ctx context.Context, repo ports.ServiceCatalogRepo, name string) (entities.Service, error) {
+	alias := normalizeAlias(name)
+	if alias == "" {
+		return entities.Service{}, errors.New("invalid data: service name is required")
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		services, err := repo.FindServicesByAliases(ctx, []string{alias})
+		if err != nil {
+			logger.Log.Error("Failed to resolve service name", "error", err)
+
+			return entities.Service{}, err
+		}
+
+		if len(services) > 0 {
+			return services[0], nil
+		}
+
+		service := entities.Service{
+			Name:    strings.Join(strings.Fields(name), " "),
+			Aliases: []string{alias},
+		}
+
+		service.ID, err = repo.CreateService(ctx, service)
+		if err == nil {
+			logger.Log.Info("Service added to catalog", "id", service.ID, "name", service.Name)
+
+			return service, nil
+		}
+
+		// A concurrent create may have added the same name; look it up again.
+		if !errormsgs.IsConflict(err) {
+			logger.Log.Error("Failed to add service to catalog", "error", err)
+
+			return entities.Service{}, err
+		}
+	}
+
+	return entities.Service{}, fmt.Errorf("%w: service %q could not be resolved", errormsgs.Conflict, name)
+}
+
+func toServiceDTO(service entities.Service) dto.Service {
+	return dto.Service{
+		ID:           service.ID,
+		Name:         service.Name,
+		Aliases:      service.Aliases,
+		Category:     service.Category,
+		DefaultPrice: service.DefaultPrice,
+		Website:      service.Website,
+		CreatedAt:    service.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// parseService validates the DTO and normalises its aliases, always including
+// the canonical name.
+func (s *CatalogService) parseService(ctx context.Context, serviceDTO dto.Service, id string) (entities.Service, error) {
+	name := strings.Join(strings.Fields(serviceDTO.Name), " ")
+	if name == "" {
+		return entities.Service{}, errors.New("invalid data: name is required")
+	}
+
+	if serviceDTO.DefaultPrice != nil && *serviceDTO.DefaultPrice < 0 {
+		return entities.Service{}, errors.New("invalid data: default price cannot be negative")
+	}
+
+	aliases := []string{normalizeAlias(name)}
+
+	for _, alias := range serviceDTO.Aliases {
+		alias = normalizeAlias(alias)
+
+		if alias != "" && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	owners, err := s.repo.FindServicesByAliases(ctx, aliases)
+	if err != nil {
+		logger.Log.Error("Failed to check aliases", "error", err)
+
+		return entities.Service{}, err
+	}
+
+	for _, owner := range owners {
+		if owner.ID == id {
+			continue
+		}
+
+		for _, alias := range aliases {
+			if slices.Contains(owner.Aliases, alias) {
+				logger.Log.Error("Alias already in use", "alias", alias, "service_id", owner.ID)
+
+				return entities.Service{}, fmt.Errorf("%w: alias %q already belongs to service %s (%s)", errormsgs.Conflict, alias, owner.Name, owner.ID)
+			}
+		}
+	}
+
+	return entities.Service{
+		ID:           id,
+		Name:         name,
+		Aliases:      aliases,
+		Category:     strings.TrimSpace(serviceDTO.Category),
+		DefaultPrice: serviceDTO.DefaultPrice,
+		Website:      strings.TrimSpace(serviceDTO.Website),
+	}, nil
+}
+
+func (s *CatalogService) CreateService(ctx context.Context, serviceDTO dto.Service) (string, error) {
+	logger.Log.Info("CreateService called", "name", serviceDTO.Name)
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return "", err
+	}
+
+	service, err := s.parseService(ctx, serviceDTO, "")
+	if err != nil {
+		return "", err
+	}
+
+	id, err := s.repo.CreateService(ctx, service)
+	if err != nil {
+		logger.Log.Error("Failed to create service", "error", err)
+
+		return "", err
+	}
+
+	logger.Log.Info("Service created successfully", "id", id, "name", service.Name)
+
+	return id, nil
+}
+
+func (s *CatalogService) GetServiceByID(ctx context.Context, id string) (dto.Service, error) {
+	logger.Log.Info("GetServiceByID called", "id", id)
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return dto.Service{}, err
+	}
+
+	service, err := s.repo.GetServiceByID(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get service", "error", err)
+
+		return dto.Service{}, err
+	}
+
+	return toServiceDTO(service), nil
+}
+
+func (s *CatalogService) GetServices(ctx context.Context, category string) ([]dto.Service, error) {
+	logger.Log.Info("GetServices called", "category", category)
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	services, err := s.repo.GetServices(ctx, category)
+	if err != nil {
+		logger.Log.Error("Failed to get services", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.Service, 0, len(services))
+
+	for _, service := range services {
+		result = append(result, toServiceDTO(service))
+	}
+
+	return result, nil
+}
+
+func (s *CatalogService) UpdateService(ctx context.Context, serviceDTO dto.Service, id string) error {
+	logger.Log.Info("UpdateService called", "id", id)
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+
+	service, err := s.parseService(ctx, serviceDTO, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateService(ctx, service); err != nil {
+		logger.Log.Error("Failed to update service", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Service updated successfully", "id", id)
+
+	return nil
+}
+
+func (s *CatalogService) DeleteService(ctx context.Context, id string) error {
+	logger.Log.Info("DeleteService called", "id", id)
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteService(ctx, id); err != nil {
+		logger.Log.Error("Failed to delete service", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Service deleted successfully", "id", id)
+
+	return nil
+}
+
+func (s *CatalogService) MergeServices(ctx context.Context, targetID, sourceID string) (dto.Service, error) {
+	logger.Log.Info("MergeServices called", "target_id", targetID, "source_id", sourceID)
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return dto.Service{}, err
+	}
+
+	if sourceID == "" || sourceID == targetID {
+		return dto.Service{}, errors.New("invalid data: source_id must name another service")
+	}
+
+	if err := s.repo.MergeServices(ctx, targetID, sourceID); err != nil {
+		logger.Log.Error("Failed to merge services", "error", err)
+
+		return dto.Service{}, err
+	}
+
+	logger.Log.Info("Services merged successfully", "target_id", targetID, "source_id", sourceID)
+
+	return s.GetServiceByID(ctx, targetID)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	dateLayout  = time.DateOnly
+	monthLayout = "01-2006"
+)
+
+// parseDate accepts ISO-8601 dates (YYYY-MM-DD) and the legacy MM-YYYY
+// format, which is read as the first day of the month.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(monthLayout, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid data: date %q must be YYYY-MM-DD or MM-YYYY", value)
+}
+
+// parsePeriodEnd parses an inclusive upper bound. A legacy MM-YYYY value
+// covers the whole month and is read as its last day.
+func parsePeriodEnd(value string) (time.Time, error) {
+	if t, err := time.Parse(monthLayout, value); err == nil {
+		return t.AddDate(0, 1, -1), nil
+	}
+
+	return parseDate(value)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func formatMonth(t time.Time) string {
+	return t.Format(monthLayout)
+}
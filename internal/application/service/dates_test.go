@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2025-03-15", want: time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-02-29", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{value: "03-2025", want: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "12-2025", want: time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-02-29", wantErr: true},
+		{value: "13-2025", wantErr: true},
+		{value: "2025/03/15", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDate(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseDate(%q) = %s, want error", tt.value, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseDate(%q): %v", tt.value, err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePeriodEnd(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "12-2025", want: time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "04-2025", want: time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC)},
+		{value: "02-2025", want: time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		{value: "02-2024", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-12-01", want: time.Date(2025, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-02-14", want: time.Date(2025, time.February, 14, 0, 0, 0, 0, time.UTC)},
+		{value: "00-2025", wantErr: true},
+		{value: "2025-13-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePeriodEnd(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePeriodEnd(%q) = %s, want error", tt.value, got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsePeriodEnd(%q): %v", tt.value, err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("parsePeriodEnd(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
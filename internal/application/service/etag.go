@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// expectVersions checks the If-Match header of a write against etag, the
+// current tag of subs, and returns the versions the repo must still find them
+// at when it writes. An empty or "*" header skips the check.
+func expectVersions(ifMatch, etag string, subs ...entities.Subscription) (entities.Versions, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return nil, nil
+	}
+
+	if !dto.MatchETag(ifMatch, etag, false) {
+		logger.Log.Error("If-Match does not match", "if_match", ifMatch, "etag", etag)
+
+		return nil, errormsgs.PreconditionFailed
+	}
+
+	versions := make(entities.Versions, len(subs))
+
+	for _, sub := range subs {
+		versions[sub.ID] = sub.Version
+	}
+
+	return versions, nil
+}
+
+// userSubscriptionsVersions checks ifMatch against the subscriptions of
+// userUUID as listed by GET /users/{userUUID}/subscriptions.
+func (s *SubscriptionService) userSubscriptionsVersions(ctx context.Context, userUUID, ifMatch string) (entities.Versions, error) {
+	if strings.TrimSpace(ifMatch) == "" {
+		return nil, nil
+	}
+
+	subscriptions, err := s.repo.GetSubscriptionsByUserUUID(ctx, userUUID)
+	if err != nil {
+		logger.Log.Error("Failed to get user subscriptions", "error", err)
+
+		return nil, err
+	}
+
+	return expectVersions(ifMatch, dto.CollectionETag(toSubscriptionDTOs(subscriptions)), subscriptions...)
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+type ExchangeRateService struct {
+	repo ports.ExchangeRateRepo
+}
+
+func NewExchangeRateService(repo ports.ExchangeRateRepo) *ExchangeRateService {
+	return &ExchangeRateService{
+		repo: repo,
+	}
+}
+
+func (s *ExchangeRateService) SaveExchangeRates(ctx context.Context, ratesDTO []dto.ExchangeRate) (int, error) {
+	logger.Log.Info("SaveExchangeRates called", "count", len(ratesDTO))
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	rates := make([]entities.ExchangeRate, 0, len(ratesDTO))
+
+	for i, rateDTO := range ratesDTO {
+		rate, err := parseExchangeRate(rateDTO)
+		if err != nil {
+			logger.Log.Error("Invalid exchange rate", "index", i, "error", err)
+
+			return 0, fmt.Errorf("rate %d: %w", i, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return s.save(ctx, rates)
+}
+
+// ImportExchangeRatesCSV loads rates from CSV rows of date,from,to,rate.
+// A header row is skipped when its first column is "date".
+func (s *ExchangeRateService) ImportExchangeRatesCSV(ctx context.Context, r io.Reader) (int, error) {
+	logger.Log.Info("ImportExchangeRatesCSV called")
+
+	if _, err := requireRole(ctx, auth.RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	rates := make([]entities.ExchangeRate, 0)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			logger.Log.Error("Failed to read CSV", "line", line, "error", err)
+
+			return 0, fmt.Errorf("invalid data: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		rateValue, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			logger.Log.Error("Failed to parse rate", "line", line, "error", err)
+
+			return 0, fmt.Errorf("line %d: invalid data: rate %q is not a number", line, record[3])
+		}
+
+		rate, err := parseExchangeRate(dto.ExchangeRate{
+			Date: record[0],
+			From: record[1],
+			To:   record[2],
+			Rate: rateValue,
+		})
+		if err != nil {
+			logger.Log.Error("Invalid exchange rate", "line", line, "error", err)
+
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return s.save(ctx, rates)
+}
+
+func (s *ExchangeRateService) GetExchangeRates(ctx context.Context, from, to string) ([]dto.ExchangeRate, error) {
+	logger.Log.Info("GetExchangeRates called", "from", from, "to", to)
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	fromCurrency, err := parseOptionalCurrency(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toCurrency, err := parseOptionalCurrency(to)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.repo.GetExchangeRates(ctx, fromCurrency, toCurrency)
+	if err != nil {
+		logger.Log.Error("Failed to get exchange rates", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.ExchangeRate, 0, len(rates))
+
+	for _, rate := range rates {
+		result = append(result, dto.ExchangeRate{
+			Date: rate.Date.Format(time.DateOnly),
+			From: string(rate.From),
+			To:   string(rate.To),
+			Rate: rate.Rate,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *ExchangeRateService) save(ctx context.Context, rates []entities.ExchangeRate) (int, error) {
+	type rateKey struct {
+		date     time.Time
+		from, to entities.Currency
+	}
+
+	unique := make([]entities.ExchangeRate, 0, len(rates))
+	index := make(map[rateKey]int, len(rates))
+
+	for _, rate := range rates {
+		key := rateKey{date: rate.Date, from: rate.From, to: rate.To}
+
+		if i, ok := index[key]; ok {
+			unique[i] = rate
+
+			continue
+		}
+
+		index[key] = len(unique)
+		unique = append(unique, rate)
+	}
+
+	if err := s.repo.UpsertExchangeRates(ctx, unique); err != nil {
+		logger.Log.Error("Failed to save exchange rates", "error", err)
+
+		return 0, err
+	}
+
+	logger.Log.Info("Exchange rates saved successfully", "count", len(unique))
+
+	return len(unique), nil
+}
+
+func parseExchangeRate(rateDTO dto.ExchangeRate) (entities.ExchangeRate, error) {
+	date, err := time.Parse(time.DateOnly, strings.TrimSpace(rateDTO.Date))
+	if err != nil {
+		return entities.ExchangeRate{}, fmt.Errorf("invalid data: date %q must be YYYY-MM-DD", rateDTO.Date)
+	}
+
+	from, err := parseCurrency(rateDTO.From)
+	if err != nil {
+		return entities.ExchangeRate{}, err
+	}
+
+	to, err := parseCurrency(rateDTO.To)
+	if err != nil {
+		return entities.ExchangeRate{}, err
+	}
+
+	if from == to {
+		return entities.ExchangeRate{}, errors.New("invalid data: from and to currencies must differ")
+	}
+
+	if rateDTO.Rate <= 0 {
+		return entities.ExchangeRate{}, errors.New("invalid data: rate must be positive")
+	}
+
+	return entities.ExchangeRate{
+		Date: date,
+		From: from,
+		To:   to,
+		Rate: rateDTO.Rate,
+	}, nil
+}
+
+func parseCurrency(code string) (entities.Currency, error) {
+	currency, ok := entities.NormalizeCurrency(code)
+	if !ok {
+		return "", fmt.Errorf("invalid data: unknown ISO 4217 currency %q", code)
+	}
+
+	return currency, nil
+}
+
+func parseOptionalCurrency(code string) (entities.Currency, error) {
+	if code == "" {
+		return "", nil
+	}
+
+	return parseCurrency(code)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// ExportSubscriptions calls each for every subscription matching query, with
+// the filters and policy of SearchSubscriptions but without pages: rows are
+// streamed by start date as the database returns them.
+func (s *SubscriptionService) ExportSubscriptions(ctx context.Context, query dto.SubscriptionQuery, each func(dto.Subscription) error) error {
+	logger.Log.Info("ExportSubscriptions called", "user_id", query.UserID, "service_name_in", query.ServiceNameIn)
+
+	filter, err := parseSubscriptionQuery(query)
+	if err != nil {
+		return err
+	}
+
+	if err := restrictToOwner(ctx, &filter.UserID, auth.RoleUser, auth.RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := authorizeIncludeDeleted(ctx, filter.IncludeDeleted); err != nil {
+		return err
+	}
+
+	err = s.repo.StreamSubscriptions(ctx, filter, func(sub entities.Subscription) error {
+		return each(toSubscriptionDTO(sub))
+	})
+	if err != nil {
+		logger.Log.Error("Failed to export subscriptions", "error", err)
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,528 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const (
+	maxImportRows      = 100000
+	maxImportRowErrors = 1000
+)
+
+// importFields are the subscription fields CSV columns are read into.
+var importFields = []string{
+	"service_id", "service_name", "price", "currency", "billing_period", "billing_interval",
+	"user_id", "start_date", "end_date", "trial_end_date", "split_rule", "tags",
+}
+
+// ImportService imports subscriptions from CSV files in the background,
+// validating every row with the rules of SubscriptionService.CreateSubscription.
+type ImportService struct {
+	jobs ports.ImportJobRepo
+	subs *SubscriptionService
+}
+
+func NewImportService(jobs ports.ImportJobRepo, subs *SubscriptionService) *ImportService {
+	return &ImportService{
+		jobs: jobs,
+		subs: subs,
+	}
+}
+
+// importRow is a CSV record read as a subscription to create, or the reason
+// it could not be.
+type importRow struct {
+	line int
+	sub  dto.Subscription
+	err  error
+}
+
+func toImportJobDTO(job entities.ImportJob) dto.ImportJob {
+	jobDTO := dto.ImportJob{
+		ID:           job.ID,
+		Status:       string(job.Status),
+		DryRun:       job.DryRun,
+		TotalRows:    job.TotalRows,
+		ValidRows:    job.ValidRows,
+		InvalidRows:  job.InvalidRows,
+		ImportedRows: job.ImportedRows,
+		RowErrors:    make([]dto.ImportRowError, 0, len(job.RowErrors)),
+		Error:        job.Error,
+		CreatedAt:    job.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	for _, rowError := range job.RowErrors {
+		jobDTO.RowErrors = append(jobDTO.RowErrors, dto.ImportRowError{Line: rowError.Line, Error: rowError.Error})
+	}
+
+	if job.FinishedAt != nil {
+		jobDTO.FinishedAt = job.FinishedAt.UTC().Format(time.RFC3339)
+	}
+
+	return jobDTO
+}
+
+// principalName names the principal the way the audit log does.
+func principalName(principal auth.Principal) string {
+	return principal.Method + ":" + principal.Subject
+}
+
+// ImportSubscriptionsCSV reads the CSV in r and starts a job validating its
+// rows and, unless options.DryRun is set, creating the valid ones in one
+// transaction. Errors in the file itself fail the call; errors in rows are
+// reported by the job.
+func (i *ImportService) ImportSubscriptionsCSV(ctx context.Context, options dto.ImportOptions, r io.Reader) (dto.ImportJob, error) {
+	logger.Log.Info("ImportSubscriptionsCSV called", "dry_run", options.DryRun)
+
+	principal, err := requireRole(ctx, auth.RoleUser, auth.RoleAdmin)
+	if err != nil {
+		return dto.ImportJob{}, err
+	}
+
+	layout, err := importDateLayout(options.DateFormat)
+	if err != nil {
+		return dto.ImportJob{}, err
+	}
+
+	if options.Currency != "" {
+		if _, err := parseCurrency(options.Currency); err != nil {
+			return dto.ImportJob{}, err
+		}
+	}
+
+	rows, err := readImportCSV(r, options, layout)
+	if err != nil {
+		return dto.ImportJob{}, err
+	}
+
+	job, err := i.jobs.CreateImportJob(ctx, entities.ImportJob{
+		CreatedBy: principalName(principal),
+		DryRun:    options.DryRun,
+		TotalRows: len(rows),
+	})
+	if err != nil {
+		logger.Log.Error("Failed to create import job", "error", err)
+
+		return dto.ImportJob{}, err
+	}
+
+	// The job outlives the request but keeps its principal and organisation.
+	go i.run(context.WithoutCancel(ctx), job, rows)
+
+	logger.Log.Info("Import job started", "id", job.ID, "rows", len(rows))
+
+	return toImportJobDTO(job), nil
+}
+
+// FailInterruptedImports fails the jobs a previous run of the server left
+// pending or running. Jobs run in the server process, so they cannot resume
+// after it stops; it must be called before new jobs are started.
+func (i *ImportService) FailInterruptedImports(ctx context.Context) error {
+	failed, err := i.jobs.FailUnfinishedImportJobs(ctx, "import interrupted by a server restart")
+	if err != nil {
+		logger.Log.Error("Failed to fail interrupted import jobs", "error", err)
+
+		return err
+	}
+
+	if failed > 0 {
+		logger.Log.Info("Interrupted import jobs failed", "count", failed)
+	}
+
+	return nil
+}
+
+// GetImportJob returns a job to the principal who started it, or to an admin.
+func (i *ImportService) GetImportJob(ctx context.Context, id string) (dto.ImportJob, error) {
+	logger.Log.Info("GetImportJob called", "id", id)
+
+	principal, err := requireRole(ctx, auth.RoleUser, auth.RoleAdmin)
+	if err != nil {
+		return dto.ImportJob{}, err
+	}
+
+	job, err := i.jobs.GetImportJob(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get import job", "error", err)
+
+		return dto.ImportJob{}, err
+	}
+
+	if principal.Role != auth.RoleAdmin && job.CreatedBy != principalName(principal) {
+		logger.Log.Error("Policy: not the owner of the import job", "subject", principal.Subject, "id", id)
+
+		return dto.ImportJob{}, errormsgs.Deny(reasonNotOwner)
+	}
+
+	return toImportJobDTO(job), nil
+}
+
+// run validates the rows of job and creates the valid ones, saving the
+// outcome in the job.
+func (i *ImportService) run(ctx context.Context, job entities.ImportJob, rows []importRow) {
+	job.Status = entities.ImportRunning
+	i.save(ctx, job)
+
+	catalog := &importCatalog{subs: i.subs, resolved: make(map[string]entities.Service)}
+	valid := make([]entities.Subscription, 0, len(rows))
+
+	for _, row := range rows {
+		sub, err := i.validateRow(ctx, catalog, row)
+		if err != nil {
+			job.InvalidRows++
+
+			if len(job.RowErrors) < maxImportRowErrors {
+				job.RowErrors = append(job.RowErrors, entities.ImportRowError{Line: row.line, Error: err.Error()})
+			}
+
+			continue
+		}
+
+		valid = append(valid, sub)
+	}
+
+	job.ValidRows = len(valid)
+
+	if !job.DryRun && len(valid) > 0 {
+		batch := entities.SubscriptionBatch{Services: catalog.unknown(), Create: valid}
+
+		if _, err := i.subs.repo.ApplySubscriptionBatch(ctx, batch); err != nil {
+			logger.Log.Error("Failed to import subscriptions", "id", job.ID, "error", err)
+
+			job.Status = entities.ImportFailed
+			job.Error = err.Error()
+			i.save(ctx, job)
+
+			return
+		}
+
+		job.ImportedRows = len(valid)
+	}
+
+	job.Status = entities.ImportCompleted
+	i.save(ctx, job)
+
+	logger.Log.Info("Import job finished", "id", job.ID, "valid", job.ValidRows, "invalid", job.InvalidRows, "imported", job.ImportedRows)
+}
+
+func (i *ImportService) save(ctx context.Context, job entities.ImportJob) {
+	if err := i.jobs.UpdateImportJob(ctx, job); err != nil {
+		logger.Log.Error("Failed to save import job", "id", job.ID, "status", job.Status, "error", err)
+	}
+}
+
+// validateRow checks row as CreateSubscription would check it, resolving its
+// service through catalog.
+func (i *ImportService) validateRow(ctx context.Context, catalog *importCatalog, row importRow) (entities.Subscription, error) {
+	if row.err != nil {
+		return entities.Subscription{}, row.err
+	}
+
+	sub, err := parseNewSubscription(ctx, row.sub)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	if sub.UserID == "" {
+		return entities.Subscription{}, errors.New("invalid data: user_id is required")
+	}
+
+	service, err := catalog.service(ctx, row.sub.ServiceID, row.sub.ServiceName)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	withService(&sub, service)
+
+	return sub, nil
+}
+
+// importCatalog resolves each service of an import once. It only looks
+// names up: unknown ones are added to the catalog in the transaction that
+// creates the subscriptions, so a failed import leaves no services behind.
+type importCatalog struct {
+	subs     *SubscriptionService
+	resolved map[string]entities.Service
+}
+
+func (c *importCatalog) service(ctx context.Context, id, name string) (entities.Service, error) {
+	key := id
+	if id == "" {
+		key = "alias:" + normalizeAlias(name)
+	}
+
+	if service, ok := c.resolved[key]; ok {
+		return service, nil
+	}
+
+	var service entities.Service
+	var err error
+
+	if id == "" {
+		service, err = c.lookup(ctx, name)
+	} else {
+		service, err = c.subs.catalogService(ctx, id, name)
+	}
+
+	if err != nil {
+		return entities.Service{}, err
+	}
+
+	c.resolved[key] = service
+
+	return service, nil
+}
+
+// unknown returns the services the import names that are not in the catalog.
+func (c *importCatalog) unknown() []entities.Service {
+	var services []entities.Service
+
+	for _, service := range c.resolved {
+		if service.ID == "" {
+			services = append(services, service)
+		}
+	}
+
+	return services
+}
+
+func (c *importCatalog) lookup(ctx context.Context, name string) (entities.Service, error) {
+	alias := normalizeAlias(name)
+	if alias == "" {
+		return entities.Service{}, errors.New("invalid data: service name is required")
+	}
+
+	services, err := c.subs.services.FindServicesByAliases(ctx, []string{alias})
+	if err != nil {
+		logger.Log.Error("Failed to resolve service name", "error", err)
+
+		return entities.Service{}, err
+	}
+
+	if len(services) > 0 {
+		return services[0], nil
+	}
+
+	return entities.Service{Name: strings.Join(strings.Fields(name), " "), Aliases: []string{alias}}, nil
+}
+
+// importDateLayout turns a date format spelled with YYYY, MM and DD, such as
+// DD.MM.YYYY, into a time layout. An empty format gives an empty layout. A
+// format without DD names whole months, see readImportRow.
+func importDateLayout(format string) (string, error) {
+	if format == "" {
+		return "", nil
+	}
+
+	layout := strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(strings.ToUpper(format))
+
+	rest := strings.NewReplacer("2006", "", "01", "", "02", "").Replace(layout)
+	if !strings.Contains(layout, "2006") || !strings.Contains(layout, "01") ||
+		strings.IndexFunc(rest, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+		return "", fmt.Errorf("invalid data: date_format %q must be spelled with YYYY, MM and DD, e.g. DD.MM.YYYY", format)
+	}
+
+	return layout, nil
+}
+
+// readImportCSV reads the header and every record of the CSV in r. Records
+// that cannot be read as a subscription carry their error.
+func readImportCSV(r io.Reader, options dto.ImportOptions, layout string) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid data: CSV is empty")
+	}
+
+	if err != nil {
+		logger.Log.Error("Failed to read CSV header", "error", err)
+
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+
+	columns, err := importColumns(header, options.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]importRow, 0)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			logger.Log.Error("Failed to read CSV", "error", err)
+
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("invalid data: an import takes at most %d rows", maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		rows = append(rows, readImportRow(line, record, columns, layout, options.Currency))
+	}
+
+	return rows, nil
+}
+
+// importColumns finds the column of every field in header, by its mapped
+// name or else its own, ignoring case.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, fmt.Errorf("invalid data: unknown import field %q", field)
+		}
+	}
+
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	columns := make(map[string]int, len(importFields))
+
+	for _, field := range importFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+
+		index := slices.IndexFunc(header, func(column string) bool {
+			return strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name))
+		})
+
+		if index < 0 {
+			if mapped {
+				return nil, fmt.Errorf("invalid data: column %q mapped to %s is not in the CSV header", name, field)
+			}
+
+			continue
+		}
+
+		columns[field] = index
+	}
+
+	if _, ok := columns["start_date"]; !ok {
+		return nil, errors.New("invalid data: the CSV has no start_date column")
+	}
+
+	_, hasName := columns["service_name"]
+	_, hasID := columns["service_id"]
+
+	if !hasName && !hasID {
+		return nil, errors.New("invalid data: the CSV has no service_name or service_id column")
+	}
+
+	return columns, nil
+}
+
+// readImportRow reads record as a subscription, converting dates in layout
+// to YYYY-MM-DD. A layout without a day converts end dates to MM-YYYY
+// instead, so that they cover the whole month as in the API. Tags are
+// separated by commas or semicolons.
+func readImportRow(line int, record []string, columns map[string]int, layout, currency string) importRow {
+	value := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[index])
+	}
+
+	row := importRow{
+		line: line,
+		sub: dto.Subscription{
+			ServiceID:     value("service_id"),
+			ServiceName:   value("service_name"),
+			Currency:      value("currency"),
+			BillingPeriod: value("billing_period"),
+			UserID:        value("user_id"),
+			SplitRule:     value("split_rule"),
+			Tags:          strings.FieldsFunc(value("tags"), func(r rune) bool { return r == ',' || r == ';' }),
+		},
+	}
+
+	if row.sub.Currency == "" {
+		row.sub.Currency = currency
+	}
+
+	numbers := []struct {
+		field string
+		dest  *int
+	}{
+		{"price", &row.sub.Price},
+		{"billing_interval", &row.sub.BillingInterval},
+	}
+
+	for _, number := range numbers {
+		if v := value(number.field); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				row.err = fmt.Errorf("invalid data: %s %q is not a whole number", number.field, v)
+
+				return row
+			}
+
+			*number.dest = parsed
+		}
+	}
+
+	monthOnly := layout != "" && !strings.Contains(layout, "02")
+
+	dates := []struct {
+		field     string
+		dest      *string
+		periodEnd bool
+	}{
+		{"start_date", &row.sub.StartDate, false},
+		{"end_date", &row.sub.EndDate, true},
+		{"trial_end_date", &row.sub.TrialEndDate, false},
+	}
+
+	for _, date := range dates {
+		v := value(date.field)
+		if v != "" && layout != "" {
+			parsed, err := time.Parse(layout, v)
+			if err != nil {
+				row.err = fmt.Errorf("invalid data: %s %q does not match date_format", date.field, v)
+
+				return row
+			}
+
+			if monthOnly && date.periodEnd {
+				v = parsed.Format(monthLayout)
+			} else {
+				v = formatDate(parsed)
+			}
+		}
+
+		*date.dest = v
+	}
+
+	return row
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+
+	"fmt"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// statusTransitions lists the statuses each status may move to.
+// Expired is reached only by passing the end date.
+var statusTransitions = map[entities.SubscriptionStatus][]entities.SubscriptionStatus{
+	entities.StatusTrial:     {entities.StatusActive, entities.StatusCancelled},
+	entities.StatusActive:    {entities.StatusPaused, entities.StatusCancelled},
+	entities.StatusPaused:    {entities.StatusActive, entities.StatusCancelled},
+	entities.StatusCancelled: {},
+	entities.StatusExpired:   {},
+}
+
+func canTransition(from, to entities.SubscriptionStatus) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// effectiveStatus derives the status as of today: subscriptions past their
+// end date are expired and trials past their trial end date are active.
+func effectiveStatus(sub entities.Subscription, today time.Time) entities.SubscriptionStatus {
+	if sub.EndDate != nil && sub.EndDate.Before(today) {
+		return entities.StatusExpired
+	}
+
+	if sub.Status == entities.StatusTrial && sub.TrialEndDate != nil && !sub.TrialEndDate.After(today) {
+		return entities.StatusActive
+	}
+
+	return sub.Status
+}
+
+func today() time.Time {
+	now := time.Now().UTC()
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *SubscriptionService) PauseSubscription(ctx context.Context, id string) (dto.Subscription, error) {
+	logger.Log.Info("PauseSubscription called", "id", id)
+
+	return s.changeStatus(ctx, id, entities.StatusPaused, nil)
+}
+
+func (s *SubscriptionService) ResumeSubscription(ctx context.Context, id string) (dto.Subscription, error) {
+	logger.Log.Info("ResumeSubscription called", "id", id)
+
+	return s.changeStatus(ctx, id, entities.StatusActive, nil)
+}
+
+// CancelSubscription cancels immediately or, by default, at the end of the
+// current billing period by moving the end date to the day before the next renewal.
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, id string, req dto.CancelSubscriptionRequest) (dto.Subscription, error) {
+	logger.Log.Info("CancelSubscription called", "id", id, "at_period_end", req.AtPeriodEnd)
+
+	atPeriodEnd := req.AtPeriodEnd == nil || *req.AtPeriodEnd
+
+	return s.changeStatus(ctx, id, entities.StatusCancelled, func(sub entities.Subscription, day time.Time) *time.Time {
+		endDate := day
+
+		if atPeriodEnd {
+			renewal := sub.NextRenewal(day.AddDate(0, 0, 1))
+			if renewal == nil {
+				return nil
+			}
+
+			endDate = renewal.AddDate(0, 0, -1)
+		}
+
+		if sub.EndDate != nil && sub.EndDate.Before(endDate) {
+			return nil
+		}
+
+		return &endDate
+	})
+}
+
+func (s *SubscriptionService) changeStatus(
+	ctx context.Context,
+	id string,
+	to entities.SubscriptionStatus,
+	endDate func(sub entities.Subscription, day time.Time) *time.Time,
+) (dto.Subscription, error) {
+	sub, err := s.ownedSubscription(ctx, id)
+	if err != nil {
+		return dto.Subscription{}, err
+	}
+
+	day := today()
+	current := effectiveStatus(sub, day)
+
+	if !canTransition(current, to) {
+		logger.Log.Error("Status transition not allowed", "id", id, "from", current, "to", to)
+
+		return dto.Subscription{}, fmt.Errorf("%w: cannot change status from %s to %s", errormsgs.Conflict, current, to)
+	}
+
+	change := entities.StatusChange{
+		SubscriptionID: id,
+		From:           sub.Status,
+		To:             to,
+		Date:           day,
+	}
+
+	if endDate != nil {
+		change.EndDate = endDate(sub, day)
+	}
+
+	if err := s.repo.ChangeSubscriptionStatus(ctx, change); err != nil {
+		logger.Log.Error("Failed to change subscription status", "error", err)
+
+		return dto.Subscription{}, err
+	}
+
+	logger.Log.Info("Subscription status changed successfully", "id", id, "from", current, "to", to)
+
+	return s.GetSubscriptionByID(ctx, id, false)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+func TestCanTransition(t *testing.T) {
+	statuses := []entities.SubscriptionStatus{
+		entities.StatusTrial,
+		entities.StatusActive,
+		entities.StatusPaused,
+		entities.StatusCancelled,
+		entities.StatusExpired,
+	}
+
+	allowed := map[[2]entities.SubscriptionStatus]bool{
+		{entities.StatusTrial, entities.StatusActive}:     true,
+		{entities.StatusTrial, entities.StatusCancelled}:  true,
+		{entities.StatusActive, entities.StatusPaused}:    true,
+		{entities.StatusActive, entities.StatusCancelled}: true,
+		{entities.StatusPaused, entities.StatusActive}:    true,
+		{entities.StatusPaused, entities.StatusCancelled}: true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]entities.SubscriptionStatus{from, to}]
+
+			if got := canTransition(from, to); got != want {
+				t.Errorf("canTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestEffectiveStatus(t *testing.T) {
+	day := time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)
+	yesterday := day.AddDate(0, 0, -1)
+	tomorrow := day.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name string
+		sub  entities.Subscription
+		want entities.SubscriptionStatus
+	}{
+		{
+			name: "active",
+			sub:  entities.Subscription{Status: entities.StatusActive},
+			want: entities.StatusActive,
+		},
+		{
+			name: "active until today",
+			sub:  entities.Subscription{Status: entities.StatusActive, EndDate: &day},
+			want: entities.StatusActive,
+		},
+		{
+			name: "expired after the end date",
+			sub:  entities.Subscription{Status: entities.StatusActive, EndDate: &yesterday},
+			want: entities.StatusExpired,
+		},
+		{
+			name: "paused past the end date expires",
+			sub:  entities.Subscription{Status: entities.StatusPaused, EndDate: &yesterday},
+			want: entities.StatusExpired,
+		},
+		{
+			name: "cancelled past the end date expires",
+			sub:  entities.Subscription{Status: entities.StatusCancelled, EndDate: &yesterday},
+			want: entities.StatusExpired,
+		},
+		{
+			name: "cancelled at period end",
+			sub:  entities.Subscription{Status: entities.StatusCancelled, EndDate: &tomorrow},
+			want: entities.StatusCancelled,
+		},
+		{
+			name: "trial",
+			sub:  entities.Subscription{Status: entities.StatusTrial, TrialEndDate: &tomorrow},
+			want: entities.StatusTrial,
+		},
+		{
+			name: "trial rolls to active on its end date",
+			sub:  entities.Subscription{Status: entities.StatusTrial, TrialEndDate: &day},
+			want: entities.StatusActive,
+		},
+		{
+			name: "trial rolls to active after its end date",
+			sub:  entities.Subscription{Status: entities.StatusTrial, TrialEndDate: &yesterday},
+			want: entities.StatusActive,
+		},
+		{
+			name: "trial without an end date",
+			sub:  entities.Subscription{Status: entities.StatusTrial},
+			want: entities.StatusTrial,
+		},
+		{
+			name: "expiry wins over the trial",
+			sub:  entities.Subscription{Status: entities.StatusTrial, TrialEndDate: &tomorrow, EndDate: &yesterday},
+			want: entities.StatusExpired,
+		},
+		{
+			name: "paused trial stays paused",
+			sub:  entities.Subscription{Status: entities.StatusPaused, TrialEndDate: &yesterday},
+			want: entities.StatusPaused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveStatus(tt.sub, day); got != tt.want {
+				t.Errorf("effectiveStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
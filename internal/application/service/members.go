@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// parseSplitRule validates the split rule, falling back to fallback when empty.
+func parseSplitRule(rule string, fallback entities.SplitRule) (entities.SplitRule, error) {
+	if rule == "" {
+		return fallback, nil
+	}
+
+	splitRule := entities.SplitRule(rule)
+	if !splitRule.IsValid() {
+		logger.Log.Error("Unknown split rule", "split_rule", rule)
+
+		return "", fmt.Errorf("invalid data: unknown split rule %q", rule)
+	}
+
+	return splitRule, nil
+}
+
+func toMemberDTO(member entities.SubscriptionMember) dto.SubscriptionMember {
+	memberDTO := dto.SubscriptionMember{
+		UserID:   member.UserID,
+		Share:    member.Share,
+		JoinedOn: formatDate(member.JoinedOn),
+	}
+
+	if member.LeftOn != nil {
+		memberDTO.LeftOn = formatDate(*member.LeftOn)
+	}
+
+	return memberDTO
+}
+
+// membersFrom returns the members still sharing the subscription on day.
+func membersFrom(members []entities.SubscriptionMember, day time.Time) []entities.SubscriptionMember {
+	result := make([]entities.SubscriptionMember, 0, len(members))
+
+	for _, member := range members {
+		if member.LeftOn == nil || member.LeftOn.After(day) {
+			result = append(result, member)
+		}
+	}
+
+	return result
+}
+
+// checkShares validates member shares against the split rule: percent shares
+// may add up to 100 and fixed shares to the price, the owner paying the rest.
+func checkShares(rule entities.SplitRule, price int, members []entities.SubscriptionMember) error {
+	if rule == entities.SplitEqual {
+		return nil
+	}
+
+	var total float64
+
+	for _, member := range members {
+		if member.Share == nil {
+			logger.Log.Error("Member without share", "split_rule", rule, "user_id", member.UserID)
+
+			return fmt.Errorf("invalid data: member %s needs a share for a %s split", member.UserID, rule)
+		}
+
+		total += *member.Share
+	}
+
+	switch {
+	case rule == entities.SplitPercent && total > 100:
+		logger.Log.Error("Percent shares exceed 100", "total", total)
+
+		return errors.New("invalid data: percent shares add up to more than 100")
+	case rule == entities.SplitFixed && total > float64(price):
+		logger.Log.Error("Fixed shares exceed price", "total", total, "price", price)
+
+		return errors.New("invalid data: fixed shares add up to more than the price")
+	}
+
+	return nil
+}
+
+// checkMemberShares validates the current members of subEntity against its
+// split rule and price.
+func (s *SubscriptionService) checkMemberShares(ctx context.Context, subEntity entities.Subscription) error {
+	members, err := s.repo.GetSubscriptionMembers(ctx, subEntity.ID)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription members", "error", err)
+
+		return err
+	}
+
+	return checkShares(subEntity.SplitRule, subEntity.Price, membersFrom(members, today()))
+}
+
+func (s *SubscriptionService) GetSubscriptionMembers(ctx context.Context, id string) ([]dto.SubscriptionMember, error) {
+	logger.Log.Info("GetSubscriptionMembers called", "id", id)
+
+	if _, err := s.ownedSubscription(ctx, id); err != nil {
+		return nil, err
+	}
+
+	members, err := s.repo.GetSubscriptionMembers(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription members", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.SubscriptionMember, 0, len(members))
+
+	for _, member := range members {
+		result = append(result, toMemberDTO(member))
+	}
+
+	return result, nil
+}
+
+func (s *SubscriptionService) AddSubscriptionMember(ctx context.Context, id string, req dto.AddSubscriptionMemberRequest) (dto.SubscriptionMember, error) {
+	logger.Log.Info("AddSubscriptionMember called", "id", id, "user_id", req.UserID)
+
+	sub, err := s.ownedSubscription(ctx, id)
+	if err != nil {
+		return dto.SubscriptionMember{}, err
+	}
+
+	if req.UserID == "" {
+		return dto.SubscriptionMember{}, errors.New("invalid data: user_id is required")
+	}
+
+	if req.UserID == sub.UserID {
+		return dto.SubscriptionMember{}, errors.New("invalid data: the owner already shares the subscription")
+	}
+
+	joinedOn := today()
+	if req.EffectiveDate != "" {
+		joinedOn, err = parseDate(req.EffectiveDate)
+		if err != nil {
+			logger.Log.Error("Failed to parse effective date", "error", err)
+
+			return dto.SubscriptionMember{}, err
+		}
+	}
+
+	if joinedOn.Before(sub.StartDate) {
+		return dto.SubscriptionMember{}, errors.New("invalid data: effective date cannot be before the subscription start date")
+	}
+
+	if req.Share != nil && *req.Share <= 0 {
+		return dto.SubscriptionMember{}, errors.New("invalid data: share must be positive")
+	}
+
+	if sub.SplitRule == entities.SplitEqual && req.Share != nil {
+		return dto.SubscriptionMember{}, errors.New("invalid data: share is not used by an equal split")
+	}
+
+	members, err := s.repo.GetSubscriptionMembers(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription members", "error", err)
+
+		return dto.SubscriptionMember{}, err
+	}
+
+	member := entities.SubscriptionMember{
+		SubscriptionID: id,
+		UserID:         req.UserID,
+		Share:          req.Share,
+		JoinedOn:       joinedOn,
+	}
+
+	sharing := membersFrom(members, joinedOn)
+
+	for _, existing := range sharing {
+		if existing.UserID == req.UserID {
+			logger.Log.Error("User is already a member", "id", id, "user_id", req.UserID)
+
+			return dto.SubscriptionMember{}, fmt.Errorf("%w: user %s is already a member", errormsgs.Conflict, req.UserID)
+		}
+	}
+
+	if err := checkShares(sub.SplitRule, sub.Price, append(sharing, member)); err != nil {
+		return dto.SubscriptionMember{}, err
+	}
+
+	if err := s.repo.AddSubscriptionMember(ctx, member); err != nil {
+		logger.Log.Error("Failed to add subscription member", "error", err)
+
+		return dto.SubscriptionMember{}, err
+	}
+
+	logger.Log.Info("Subscription member added successfully", "id", id, "user_id", req.UserID)
+
+	return toMemberDTO(member), nil
+}
+
+// RemoveSubscriptionMember ends a membership from effectiveDate (today when
+// empty); the member no longer pays for months ending on or after it.
+func (s *SubscriptionService) RemoveSubscriptionMember(ctx context.Context, id, userID, effectiveDate string) error {
+	logger.Log.Info("RemoveSubscriptionMember called", "id", id, "user_id", userID, "effective_date", effectiveDate)
+
+	if _, err := s.ownedSubscription(ctx, id); err != nil {
+		return err
+	}
+
+	leftOn := today()
+	if effectiveDate != "" {
+		parsed, err := parseDate(effectiveDate)
+		if err != nil {
+			logger.Log.Error("Failed to parse effective date", "error", err)
+
+			return err
+		}
+
+		leftOn = parsed
+	}
+
+	members, err := s.repo.GetSubscriptionMembers(ctx, id)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription members", "error", err)
+
+		return err
+	}
+
+	for _, member := range members {
+		if member.UserID == userID && member.LeftOn == nil && leftOn.Before(member.JoinedOn) {
+			return errors.New("invalid data: effective date cannot be before the member joined")
+		}
+	}
+
+	if err := s.repo.RemoveSubscriptionMember(ctx, id, userID, leftOn); err != nil {
+		logger.Log.Error("Failed to remove subscription member", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscription member removed successfully", "id", id, "user_id", userID)
+
+	return nil
+}
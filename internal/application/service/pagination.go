@@ -0,0 +1,162 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// pageCursor is the JSON payload behind the opaque cursor string. Sort and
+// order are kept so a cursor cannot be replayed against a different ordering.
+type pageCursor struct {
+	Sort  entities.SortField `json:"s"`
+	Desc  bool               `json:"d,omitempty"`
+	Value string             `json:"v"`
+	ID    string             `json:"id"`
+}
+
+// parsePageRequest defaults to start_date order, or to best matches first
+// when searching.
+func parsePageRequest(limit int, cursor, sort, order string, searching bool) (entities.PageRequest, error) {
+	page := entities.PageRequest{
+		SortBy: entities.SortByStartDate,
+		Limit:  defaultPageLimit,
+	}
+
+	if searching && sort == "" {
+		page.SortBy = entities.SortByRelevance
+
+		if order == "" {
+			order = "desc"
+		}
+	}
+
+	if sort != "" {
+		page.SortBy = entities.SortField(sort)
+
+		if !page.SortBy.IsValid() {
+			logger.Log.Error("Unknown sort field", "sort", sort)
+
+			return entities.PageRequest{}, fmt.Errorf("invalid data: unknown sort field %q", sort)
+		}
+
+		if page.SortBy == entities.SortByRelevance && !searching {
+			return entities.PageRequest{}, errors.New("invalid data: sort by relevance requires q")
+		}
+	}
+
+	switch strings.ToLower(order) {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		logger.Log.Error("Unknown sort order", "order", order)
+
+		return entities.PageRequest{}, fmt.Errorf("invalid data: unknown order %q", order)
+	}
+
+	if limit < 0 || limit > maxPageLimit {
+		logger.Log.Error("Limit out of range", "limit", limit)
+
+		return entities.PageRequest{}, fmt.Errorf("invalid data: limit must be between 1 and %d", maxPageLimit)
+	}
+
+	if limit > 0 {
+		page.Limit = uint64(limit)
+	}
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor, page)
+		if err != nil {
+			logger.Log.Error("Invalid cursor", "error", err)
+
+			return entities.PageRequest{}, err
+		}
+
+		page.After = after
+	}
+
+	return page, nil
+}
+
+func encodeCursor(page entities.PageRequest, sub entities.Subscription) string {
+	payload := pageCursor{
+		Sort: page.SortBy,
+		Desc: page.Descending,
+		ID:   sub.ID,
+	}
+
+	switch page.SortBy {
+	case entities.SortByPrice:
+		payload.Value = strconv.Itoa(sub.Price)
+	case entities.SortByStartDate:
+		payload.Value = formatDate(sub.StartDate)
+	case entities.SortByServiceName:
+		payload.Value = sub.ServiceName
+	case entities.SortByRelevance:
+		payload.Value = strconv.FormatFloat(sub.Relevance, 'g', -1, 64)
+	}
+
+	data, _ := json.Marshal(payload)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, page entities.PageRequest) (*entities.PageCursor, error) {
+	errInvalid := errors.New("invalid data: malformed cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	var payload pageCursor
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" {
+		return nil, errInvalid
+	}
+
+	if payload.Sort != page.SortBy || payload.Desc != page.Descending {
+		return nil, errors.New("invalid data: cursor was issued for a different sort order")
+	}
+
+	after := &entities.PageCursor{ID: payload.ID}
+
+	switch payload.Sort {
+	case entities.SortByPrice:
+		price, err := strconv.Atoi(payload.Value)
+		if err != nil {
+			return nil, errInvalid
+		}
+
+		after.Value = price
+	case entities.SortByStartDate:
+		date, err := parseDate(payload.Value)
+		if err != nil {
+			return nil, errInvalid
+		}
+
+		after.Value = date
+	case entities.SortByRelevance:
+		relevance, err := strconv.ParseFloat(payload.Value, 64)
+		if err != nil {
+			return nil, errInvalid
+		}
+
+		after.Value = relevance
+	default:
+		after.Value = payload.Value
+	}
+
+	return after, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	sub := entities.Subscription{
+		ID:          "6f1c2b9e-2f0a-4c55-9d7e-0b8a1d3c4e5f",
+		ServiceName: "Yandex Plus",
+		Price:       399,
+		StartDate:   time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Relevance:   0.4375,
+	}
+
+	tests := []struct {
+		sort entities.SortField
+		desc bool
+		want any
+	}{
+		{entities.SortByPrice, false, 399},
+		{entities.SortByPrice, true, 399},
+		{entities.SortByStartDate, false, sub.StartDate},
+		{entities.SortByServiceName, true, "Yandex Plus"},
+		{entities.SortByRelevance, true, 0.4375},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			page := entities.PageRequest{SortBy: tt.sort, Descending: tt.desc}
+
+			after, err := decodeCursor(encodeCursor(page, sub), page)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+
+			if after.ID != sub.ID {
+				t.Errorf("ID = %q, want %q", after.ID, sub.ID)
+			}
+
+			if date, ok := tt.want.(time.Time); ok {
+				if got, ok := after.Value.(time.Time); !ok || !got.Equal(date) {
+					t.Errorf("Value = %v, want %v", after.Value, date)
+				}
+
+				return
+			}
+
+			if after.Value != tt.want {
+				t.Errorf("Value = %#v, want %#v", after.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	page := entities.PageRequest{SortBy: entities.SortByPrice}
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+
+	valid := encodeCursor(page, entities.Subscription{ID: "a", Price: 100})
+
+	tests := []struct {
+		name   string
+		cursor string
+		page   entities.PageRequest
+		reason string
+	}{
+		{"not base64", "!!!", page, "malformed"},
+		{"truncated", valid[:len(valid)-4], page, "malformed"},
+		{"not JSON", encode("price:100"), page, "malformed"},
+		{"no id", encode(`{"s":"price","v":"100"}`), page, "malformed"},
+		{"value of the wrong type", encode(`{"s":"price","v":"cheap","id":"a"}`), page, "malformed"},
+		{"bad date", encode(`{"s":"start_date","v":"2025-02-30","id":"a"}`), entities.PageRequest{SortBy: entities.SortByStartDate}, "malformed"},
+		{"other sort", valid, entities.PageRequest{SortBy: entities.SortByStartDate}, "different sort order"},
+		{"other order", valid, entities.PageRequest{SortBy: entities.SortByPrice, Descending: true}, "different sort order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, tt.page)
+			if err == nil {
+				t.Fatal("decodeCursor accepted a tampered cursor")
+			}
+
+			if !strings.HasPrefix(err.Error(), "invalid data:") || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("error = %q, want invalid data mentioning %q", err, tt.reason)
+			}
+		})
+	}
+}
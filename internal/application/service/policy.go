@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// Reasons policy denials are reported with.
+const (
+	reasonUnauthenticated = "unauthenticated"
+	reasonRoleNotAllowed  = "role_not_allowed"
+	reasonNotOwner        = "not_owner"
+)
+
+// requireRole allows the principal of ctx when it holds one of roles.
+func requireRole(ctx context.Context, roles ...auth.Role) (auth.Principal, error) {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !ok {
+		logger.Log.Error("Policy: no principal")
+
+		return auth.Principal{}, errormsgs.Deny(reasonUnauthenticated)
+	}
+
+	if !slices.Contains(roles, principal.Role) {
+		logger.Log.Error("Policy: role not allowed", "subject", principal.Subject, "role", principal.Role)
+
+		return principal, errormsgs.Deny(reasonRoleNotAllowed)
+	}
+
+	return principal, nil
+}
+
+// authorizeOwner allows admins, and users acting on subscriptions of userID
+// when it is their own.
+func authorizeOwner(ctx context.Context, userID string) error {
+	principal, err := requireRole(ctx, auth.RoleUser, auth.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	if principal.Role == auth.RoleUser && principal.UserID != userID {
+		logger.Log.Error("Policy: not the owner", "subject", principal.Subject, "user_id", userID)
+
+		return errormsgs.Deny(reasonNotOwner)
+	}
+
+	return nil
+}
+
+// restrictToOwner allows principals holding one of roles, narrowing users to
+// their own subscriptions: an empty userID becomes theirs and any other is
+// denied.
+func restrictToOwner(ctx context.Context, userID *string, roles ...auth.Role) error {
+	principal, err := requireRole(ctx, roles...)
+	if err != nil {
+		return err
+	}
+
+	if principal.Role != auth.RoleUser {
+		return nil
+	}
+
+	if *userID == "" {
+		*userID = principal.UserID
+	}
+
+	if *userID != principal.UserID {
+		logger.Log.Error("Policy: not the owner", "subject", principal.Subject, "user_id", *userID)
+
+		return errormsgs.Deny(reasonNotOwner)
+	}
+
+	return nil
+}
+
+// authorizeIncludeDeleted allows only admins to read deleted subscriptions.
+func authorizeIncludeDeleted(ctx context.Context, includeDeleted bool) error {
+	if !includeDeleted {
+		return nil
+	}
+
+	_, err := requireRole(ctx, auth.RoleAdmin)
+
+	return err
+}
+
+// ownedSubscription returns the subscription when the principal of ctx may
+// act on it. Deleted subscriptions are NotFound.
+func (s *SubscriptionService) ownedSubscription(ctx context.Context, id string) (entities.Subscription, error) {
+	return s.ownedSubscriptionWith(ctx, id, false)
+}
+
+func (s *SubscriptionService) ownedSubscriptionWith(ctx context.Context, id string, includeDeleted bool) (entities.Subscription, error) {
+	sub, err := s.repo.GetSubscriptionByID(ctx, id, includeDeleted)
+	if err != nil {
+		logger.Log.Error("Failed to get subscription", "error", err)
+
+		return entities.Subscription{}, err
+	}
+
+	if err := authorizeOwner(ctx, sub.UserID); err != nil {
+		return entities.Subscription{}, err
+	}
+
+	return sub, nil
+}
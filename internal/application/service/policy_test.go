@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+const (
+	ownUserID   = "11111111-1111-1111-1111-111111111111"
+	otherUserID = "22222222-2222-2222-2222-222222222222"
+)
+
+func asRole(role auth.Role) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{
+		Subject: "test",
+		Role:    role,
+		UserID:  ownUserID,
+	})
+}
+
+// checkDenial fails unless err is a denial for reason, or nil when reason is "".
+func checkDenial(t *testing.T, err error, reason string) {
+	t.Helper()
+
+	if reason == "" {
+		if err != nil {
+			t.Fatalf("err = %v, want allowed", err)
+		}
+
+		return
+	}
+
+	if !errormsgs.IsForbidden(err) {
+		t.Fatalf("err = %v, want a denial", err)
+	}
+
+	if got := errormsgs.DenialReason(err); got != reason {
+		t.Errorf("reason = %q, want %q", got, reason)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		roles  []auth.Role
+		reason string
+	}{
+		{"no principal", context.Background(), []auth.Role{auth.RoleAdmin}, reasonUnauthenticated},
+		{"role held", asRole(auth.RoleAnalyst), []auth.Role{auth.RoleAnalyst, auth.RoleAdmin}, ""},
+		{"role not held", asRole(auth.RoleUser), []auth.Role{auth.RoleAnalyst, auth.RoleAdmin}, reasonRoleNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := requireRole(tt.ctx, tt.roles...)
+			checkDenial(t, err, tt.reason)
+		})
+	}
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		userID string
+		reason string
+	}{
+		{"no principal", context.Background(), ownUserID, reasonUnauthenticated},
+		{"user on own", asRole(auth.RoleUser), ownUserID, ""},
+		{"user on another", asRole(auth.RoleUser), otherUserID, reasonNotOwner},
+		{"analyst", asRole(auth.RoleAnalyst), ownUserID, reasonRoleNotAllowed},
+		{"admin on another", asRole(auth.RoleAdmin), otherUserID, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDenial(t, authorizeOwner(tt.ctx, tt.userID), tt.reason)
+		})
+	}
+}
+
+func TestRestrictToOwner(t *testing.T) {
+	readers := []auth.Role{auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		roles  []auth.Role
+		userID string
+		want   string
+		reason string
+	}{
+		{"no principal", context.Background(), readers, "", "", reasonUnauthenticated},
+		{"user without user_id gets their own", asRole(auth.RoleUser), readers, "", ownUserID, ""},
+		{"user on own", asRole(auth.RoleUser), readers, ownUserID, ownUserID, ""},
+		{"user on another", asRole(auth.RoleUser), readers, otherUserID, otherUserID, reasonNotOwner},
+		{"analyst sees everyone", asRole(auth.RoleAnalyst), readers, "", "", ""},
+		{"analyst on another", asRole(auth.RoleAnalyst), readers, otherUserID, otherUserID, ""},
+		{"analyst not allowed", asRole(auth.RoleAnalyst), []auth.Role{auth.RoleUser, auth.RoleAdmin}, "", "", reasonRoleNotAllowed},
+		{"admin sees everyone", asRole(auth.RoleAdmin), readers, "", "", ""},
+		{"admin on another", asRole(auth.RoleAdmin), readers, otherUserID, otherUserID, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := tt.userID
+
+			checkDenial(t, restrictToOwner(tt.ctx, &userID, tt.roles...), tt.reason)
+
+			if userID != tt.want {
+				t.Errorf("user_id = %q, want %q", userID, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		includeDeleted bool
+		reason         string
+	}{
+		{"not asked", asRole(auth.RoleUser), false, ""},
+		{"not asked without principal", context.Background(), false, ""},
+		{"user", asRole(auth.RoleUser), true, reasonRoleNotAllowed},
+		{"analyst", asRole(auth.RoleAnalyst), true, reasonRoleNotAllowed},
+		{"admin", asRole(auth.RoleAdmin), true, ""},
+		{"no principal", context.Background(), true, reasonUnauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkDenial(t, authorizeIncludeDeleted(tt.ctx, tt.includeDeleted), tt.reason)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const (
+	defaultRetentionDays = 30
+	defaultPurgeInterval = time.Hour
+)
+
+// SubscriptionPurger removes deleted subscriptions once they have been
+// deleted for longer than the retention period.
+type SubscriptionPurger struct {
+	repo      ports.SubscriptionRepo
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewSubscriptionPurger reads SUBSCRIPTION_RETENTION_DAYS (default 30) and
+// SUBSCRIPTION_PURGE_INTERVAL, a duration such as "30m" (default 1h).
+func NewSubscriptionPurger(repo ports.SubscriptionRepo) (*SubscriptionPurger, error) {
+	retentionDays := defaultRetentionDays
+
+	if value := os.Getenv("SUBSCRIPTION_RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("SUBSCRIPTION_RETENTION_DAYS must be a non-negative number of days, got %q", value)
+		}
+
+		retentionDays = days
+	}
+
+	interval := defaultPurgeInterval
+
+	if value := os.Getenv("SUBSCRIPTION_PURGE_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("SUBSCRIPTION_PURGE_INTERVAL must be a positive duration, got %q", value)
+		}
+
+		interval = parsed
+	}
+
+	return &SubscriptionPurger{
+		repo:      repo,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		interval:  interval,
+	}, nil
+}
+
+// Run purges once at start and then every interval until ctx is done.
+func (p *SubscriptionPurger) Run(ctx context.Context) {
+	logger.Log.Info("Subscription purge started", "retention", p.retention, "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the subscriptions deleted before the retention period.
+// Failures are logged and retried on the next run.
+func (p *SubscriptionPurger) Purge(ctx context.Context) {
+	cutoff := time.Now().UTC().Add(-p.retention)
+
+	purged, err := p.repo.PurgeDeletedSubscriptions(ctx, cutoff)
+	if err != nil {
+		logger.Log.Error("Failed to purge deleted subscriptions", "error", err)
+
+		return
+	}
+
+	logger.Log.Info("Deleted subscriptions purged", "cutoff", cutoff, "count", purged)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 50
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// searchTerms returns the trimmed query and, when it contains Cyrillic
+// letters, its Latin transliteration so "нетфликс" also finds "Netflix".
+func searchTerms(q string) []string {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil
+	}
+
+	var latin strings.Builder
+
+	transliterated := false
+
+	for _, r := range strings.ToLower(q) {
+		if l, ok := cyrillicToLatin[r]; ok {
+			latin.WriteString(l)
+			transliterated = true
+
+			continue
+		}
+
+		latin.WriteRune(r)
+	}
+
+	if !transliterated {
+		return []string{q}
+	}
+
+	return []string{q, latin.String()}
+}
+
+func (s *SubscriptionService) SuggestServiceNames(ctx context.Context, prefix string, limit int) ([]dto.ServiceSuggestion, error) {
+	logger.Log.Info("SuggestServiceNames called", "prefix", prefix, "limit", limit)
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	prefixes := searchTerms(prefix)
+	if len(prefixes) == 0 {
+		return nil, errors.New("invalid data: prefix is required")
+	}
+
+	if limit < 0 || limit > maxSuggestLimit {
+		logger.Log.Error("Limit out of range", "limit", limit)
+
+		return nil, fmt.Errorf("invalid data: limit must be between 1 and %d", maxSuggestLimit)
+	}
+
+	if limit == 0 {
+		limit = defaultSuggestLimit
+	}
+
+	suggestions, err := s.repo.SuggestServiceNames(ctx, prefixes, uint64(limit))
+	if err != nil {
+		logger.Log.Error("Failed to suggest service names", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.ServiceSuggestion, 0, len(suggestions))
+
+	for _, suggestion := range suggestions {
+		result = append(result, dto.ServiceSuggestion{
+			ServiceName:   suggestion.ServiceName,
+			Subscriptions: suggestion.Subscriptions,
+		})
+	}
+
+	return result, nil
+}
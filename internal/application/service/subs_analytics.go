@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const (
+	defaultAggregationLimit = 100
+	maxAggregationLimit     = 1000
+)
+
+func (s *SubscriptionService) SumSubscriptions(ctx context.Context, req dto.SumSubscriptionsRequest) (dto.SumSubscriptionsResponse, error) {
+	logger.Log.Info("SumSubscriptions called", "user_id", req.UserID, "service_name", req.ServiceName)
+
+	filter, err := parseCostFilter(req)
+	if err != nil {
+		return dto.SumSubscriptionsResponse{}, err
+	}
+
+	if err := restrictToOwner(ctx, &filter.UserID, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return dto.SumSubscriptionsResponse{}, err
+	}
+
+	groupBy, err := parseGroupBy(req.GroupBy)
+	if err != nil {
+		return dto.SumSubscriptionsResponse{}, err
+	}
+
+	if err := s.checkExchangeRates(ctx, filter); err != nil {
+		return dto.SumSubscriptionsResponse{}, err
+	}
+
+	costs, err := s.repo.SumSubscriptions(ctx, filter)
+	if err != nil {
+		logger.Log.Error("Failed to sum subscriptions", "error", err)
+
+		return dto.SumSubscriptionsResponse{}, err
+	}
+
+	resp := dto.SumSubscriptionsResponse{
+		Currency: string(filter.Currency),
+		Items:    make([]dto.SubscriptionCost, 0, len(costs)),
+	}
+
+	for _, cost := range costs {
+		resp.Total += cost.Cost
+
+		resp.Items = append(resp.Items, dto.SubscriptionCost{
+			SubscriptionID:  cost.SubscriptionID,
+			ServiceName:     cost.ServiceName,
+			UserID:          cost.UserID,
+			Price:           cost.Price,
+			Currency:        string(cost.Currency),
+			BillingPeriod:   string(cost.BillingPeriod),
+			BillingInterval: cost.BillingInterval,
+			MonthlyCost:     cost.MonthlyCost,
+			Months:          cost.Months,
+			Cost:            cost.Cost,
+		})
+	}
+
+	resp.Total = roundCost(resp.Total)
+
+	if len(groupBy) > 0 {
+		rows, err := s.repo.AggregateSubscriptions(ctx, entities.AggregationSpec{
+			CostFilter: filter,
+			GroupBy:    groupBy,
+			SortBy:     entities.MetricTotal,
+			Descending: true,
+		})
+		if err != nil {
+			logger.Log.Error("Failed to group subscription costs", "error", err)
+
+			return dto.SumSubscriptionsResponse{}, err
+		}
+
+		resp.Groups = make([]dto.AggregateSubscriptionsRow, 0, len(rows))
+
+		for _, row := range rows {
+			resp.Groups = append(resp.Groups, toAggregateRowDTO(row, filter.Currency))
+		}
+	}
+
+	logger.Log.Info("SumSubscriptions completed", "user_id", req.UserID, "service_name", req.ServiceName, "total", resp.Total, "currency", resp.Currency)
+
+	return resp, nil
+}
+
+func (s *SubscriptionService) MonthlySubscriptionCosts(ctx context.Context, req dto.SumSubscriptionsRequest) ([]dto.MonthlyCost, error) {
+	logger.Log.Info("MonthlySubscriptionCosts called", "user_id", req.UserID, "service_name", req.ServiceName)
+
+	filter, err := parseCostFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restrictToOwner(ctx, &filter.UserID, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if filter.StartPeriod == nil {
+		defaultStart := filter.EndPeriod.AddDate(0, -11, 0)
+		filter.StartPeriod = &defaultStart
+	}
+
+	if err := s.checkExchangeRates(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	costs, err := s.repo.MonthlySubscriptionCosts(ctx, filter)
+	if err != nil {
+		logger.Log.Error("Failed to get monthly subscription costs", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.MonthlyCost, 0, len(costs))
+
+	for _, cost := range costs {
+		result = append(result, dto.MonthlyCost{
+			Month:       formatMonth(cost.Month),
+			Total:       cost.Total,
+			Currency:    string(filter.Currency),
+			ActiveCount: cost.ActiveCount,
+		})
+	}
+
+	logger.Log.Info("MonthlySubscriptionCosts completed", "user_id", req.UserID, "service_name", req.ServiceName, "months", len(result))
+
+	return result, nil
+}
+
+func (s *SubscriptionService) AggregateSubscriptions(ctx context.Context, req dto.AggregateSubscriptionsRequest) ([]dto.AggregateSubscriptionsRow, error) {
+	logger.Log.Info("AggregateSubscriptions called", "group_by", req.GroupBy, "user_id", req.UserID, "service_name", req.ServiceName)
+
+	filter, err := parseCostFilter(dto.SumSubscriptionsRequest{
+		UserID:         req.UserID,
+		ServiceName:    req.ServiceName,
+		StartPeriod:    req.StartPeriod,
+		EndPeriod:      req.EndPeriod,
+		TargetCurrency: req.TargetCurrency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restrictToOwner(ctx, &filter.UserID, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	groupBy, err := parseGroupBy(req.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := entities.AggregationSpec{
+		CostFilter: filter,
+		GroupBy:    groupBy,
+		SortBy:     entities.MetricTotal,
+		Descending: true,
+		Limit:      defaultAggregationLimit,
+	}
+
+	if req.SortBy != "" {
+		metric := entities.AggregationMetric(req.SortBy)
+
+		switch metric {
+		case entities.MetricTotal, entities.MetricCount, entities.MetricAverage:
+			spec.SortBy = metric
+		default:
+			logger.Log.Error("Unknown sort_by metric", "sort_by", req.SortBy)
+
+			return nil, fmt.Errorf("invalid data: unknown sort_by metric %q", req.SortBy)
+		}
+	}
+
+	switch strings.ToLower(req.Order) {
+	case "", "desc":
+	case "asc":
+		spec.Descending = false
+	default:
+		logger.Log.Error("Unknown sort order", "order", req.Order)
+
+		return nil, fmt.Errorf("invalid data: unknown order %q", req.Order)
+	}
+
+	if req.Limit < 0 || req.Limit > maxAggregationLimit {
+		logger.Log.Error("Limit out of range", "limit", req.Limit)
+
+		return nil, fmt.Errorf("invalid data: limit must be between 1 and %d", maxAggregationLimit)
+	}
+
+	if req.Limit > 0 {
+		spec.Limit = uint64(req.Limit)
+	}
+
+	if err := s.checkExchangeRates(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.AggregateSubscriptions(ctx, spec)
+	if err != nil {
+		logger.Log.Error("Failed to aggregate subscriptions", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.AggregateSubscriptionsRow, 0, len(rows))
+
+	for _, row := range rows {
+		result = append(result, toAggregateRowDTO(row, filter.Currency))
+	}
+
+	logger.Log.Info("AggregateSubscriptions completed", "groups", len(result))
+
+	return result, nil
+}
+
+// parseGroupBy validates group_by dimensions, dropping repeated ones.
+func parseGroupBy(fields []string) ([]entities.AggregationDimension, error) {
+	groupBy := make([]entities.AggregationDimension, 0, len(fields))
+
+	for _, field := range fields {
+		dimension := entities.AggregationDimension(field)
+
+		switch dimension {
+		case entities.DimensionServiceName, entities.DimensionUserID, entities.DimensionMonth, entities.DimensionTag:
+		default:
+			logger.Log.Error("Unknown group_by dimension", "group_by", field)
+
+			return nil, fmt.Errorf("invalid data: unknown group_by dimension %q", field)
+		}
+
+		if !slices.Contains(groupBy, dimension) {
+			groupBy = append(groupBy, dimension)
+		}
+	}
+
+	return groupBy, nil
+}
+
+func toAggregateRowDTO(row entities.AggregationRow, currency entities.Currency) dto.AggregateSubscriptionsRow {
+	item := dto.AggregateSubscriptionsRow{
+		ServiceName: row.ServiceName,
+		UserID:      row.UserID,
+		Total:       row.Total,
+		Count:       row.Count,
+		Average:     row.Average,
+		Currency:    string(currency),
+	}
+
+	if row.Month != nil {
+		item.Month = formatMonth(*row.Month)
+	}
+
+	if row.Tag != nil {
+		item.Tag = *row.Tag
+	}
+
+	return item
+}
+
+// checkExchangeRates fails when a subscription in the filter cannot be
+// converted to the target currency for one of its active months.
+func (s *SubscriptionService) checkExchangeRates(ctx context.Context, filter entities.CostFilter) error {
+	missing, err := s.repo.MissingExchangeRates(ctx, filter)
+	if err != nil {
+		logger.Log.Error("Failed to check exchange rates", "error", err)
+
+		return err
+	}
+
+	if len(missing) > 0 {
+		first := missing[0]
+
+		logger.Log.Error("Missing exchange rates", "count", len(missing), "from", first.From, "to", first.To, "month", first.Month)
+
+		return fmt.Errorf(
+			"invalid data: no exchange rate from %s to %s for %s (%d missing in total)",
+			first.From, first.To, formatMonth(first.Month), len(missing),
+		)
+	}
+
+	return nil
+}
+
+// parseCostFilter parses the request filters. A missing end period defaults to
+// the current month, a missing start period is left nil and a missing target
+// currency defaults to entities.DefaultCurrency.
+func parseCostFilter(req dto.SumSubscriptionsRequest) (entities.CostFilter, error) {
+	filter := entities.CostFilter{
+		UserID:      req.UserID,
+		ServiceName: req.ServiceName,
+		Currency:    entities.DefaultCurrency,
+	}
+
+	if req.TargetCurrency != "" {
+		currency, err := parseCurrency(req.TargetCurrency)
+		if err != nil {
+			logger.Log.Error("Failed to parse target currency", "error", err)
+
+			return entities.CostFilter{}, err
+		}
+
+		filter.Currency = currency
+	}
+
+	if req.StartPeriod != "" {
+		t, err := parseDate(req.StartPeriod)
+		if err != nil {
+			logger.Log.Error("Failed to parse start period", "error", err)
+
+			return entities.CostFilter{}, err
+		}
+
+		filter.StartPeriod = &t
+	}
+
+	now := time.Now().UTC()
+	filter.EndPeriod = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if req.EndPeriod != "" {
+		t, err := parseDate(req.EndPeriod)
+		if err != nil {
+			logger.Log.Error("Failed to parse end period", "error", err)
+
+			return entities.CostFilter{}, err
+		}
+		filter.EndPeriod = t
+	}
+
+	if filter.StartPeriod != nil && filter.EndPeriod.Before(*filter.StartPeriod) {
+		logger.Log.Error("End period cannot be before start period", "start_date", req.StartPeriod, "end_date", req.EndPeriod)
+
+		return entities.CostFilter{}, errors.New("invalid data: end period cannot be before start period")
+	}
+
+	return filter, nil
+}
@@ -1,113 +1,415 @@
 package service
 
 import (
+	"context"
+
 	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/agl/online_subs/internal/application/dto"
 	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/auth"
 	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
 	"github.com/agl/online_subs/pkg/logger"
 )
 
 type SubscriptionService struct {
-	repo ports.SubscriptionRepo
+	repo     ports.SubscriptionRepo
+	services ports.ServiceCatalogRepo
 }
 
-func NewSubsService(repo ports.SubscriptionRepo) *SubscriptionService {
+func NewSubsService(repo ports.SubscriptionRepo, services ports.ServiceCatalogRepo) *SubscriptionService {
 	return &SubscriptionService{
-		repo: repo,
+		repo:     repo,
+		services: services,
+	}
+}
+
+func toSubscriptionDTO(sub entities.Subscription) dto.Subscription {
+	monthlyCost := sub.MonthlyCost()
+
+	subDTO := dto.Subscription{
+		ID:              sub.ID,
+		ServiceID:       sub.ServiceID,
+		ServiceName:     sub.ServiceName,
+		Price:           sub.Price,
+		Currency:        string(sub.Currency),
+		BillingPeriod:   string(sub.BillingPeriod),
+		BillingInterval: sub.BillingInterval,
+		MonthlyCost:     roundCost(monthlyCost),
+		AnnualCost:      roundCost(monthlyCost * 12),
+		UserID:          sub.UserID,
+		StartDate:       formatDate(sub.StartDate),
+		Relevance:       sub.Relevance,
+		SplitRule:       string(sub.SplitRule),
+		Tags:            sub.Tags,
+		Version:         sub.Version,
+	}
+
+	if sub.EndDate != nil {
+		subDTO.EndDate = formatDate(*sub.EndDate)
+	}
+
+	if renewal := sub.NextRenewal(time.Now().UTC()); renewal != nil {
+		subDTO.NextRenewalDate = formatDate(*renewal)
+	}
+
+	if sub.Status != "" {
+		subDTO.Status = string(effectiveStatus(sub, today()))
+	}
+
+	if sub.TrialEndDate != nil {
+		subDTO.TrialEndDate = formatDate(*sub.TrialEndDate)
+	}
+
+	if !sub.StatusChangedAt.IsZero() {
+		subDTO.StatusChangedAt = sub.StatusChangedAt.UTC().Format(time.RFC3339)
+	}
+
+	if sub.CancelledAt != nil {
+		subDTO.CancelledAt = sub.CancelledAt.UTC().Format(time.RFC3339)
+	}
+
+	if sub.DeletedAt != nil {
+		subDTO.DeletedAt = sub.DeletedAt.UTC().Format(time.RFC3339)
 	}
+
+	return subDTO
+}
+
+func roundCost(cost float64) float64 {
+	return math.Round(cost*100) / 100
 }
 
-func (s *SubscriptionService) CreateSubscription(subDto dto.Subscription) error {
+// parseBillingPeriod validates the billing period and interval, falling back
+// to fallback for an empty period and to 1 for a zero interval.
+func parseBillingPeriod(period string, interval int, fallback entities.BillingPeriod) (entities.BillingPeriod, int, error) {
+	billingPeriod := entities.BillingPeriod(period)
+	if period == "" {
+		billingPeriod = fallback
+	}
+
+	if billingPeriod != "" && !billingPeriod.IsValid() {
+		logger.Log.Error("Unknown billing period", "billing_period", period)
+
+		return "", 0, fmt.Errorf("invalid data: unknown billing period %q", period)
+	}
+
+	if interval < 0 {
+		logger.Log.Error("Negative billing interval", "billing_interval", interval)
+
+		return "", 0, errors.New("invalid data: billing interval must be positive")
+	}
+
+	if interval == 0 && fallback != "" {
+		interval = 1
+	}
+
+	return billingPeriod, interval, nil
+}
+
+func toSubscriptionDTOs(subs []entities.Subscription) []dto.Subscription {
+	result := make([]dto.Subscription, 0, len(subs))
+
+	for _, sub := range subs {
+		result = append(result, toSubscriptionDTO(sub))
+	}
+
+	return result
+}
+
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, subDto dto.Subscription) (string, error) {
 	logger.Log.Info("CreateSubscription called", "user_id", subDto.UserID, "service_name", subDto.ServiceName)
 
-	startDateParsed, err := time.Parse("01-2006", subDto.StartDate)
+	subEntity, err := s.newSubscription(ctx, subDto)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := s.repo.CreateSubscription(ctx, subEntity)
+	if err != nil {
+		logger.Log.Error("Failed to create subscription", "error", err)
+
+		return "", err
+	}
+
+	logger.Log.Info("Subscription created successfully", "id", id, "user_id", subEntity.UserID, "service_name", subDto.ServiceName)
+
+	return id, nil
+}
+
+// newSubscription validates subDto as a subscription to create and resolves
+// its catalog service.
+func (s *SubscriptionService) newSubscription(ctx context.Context, subDto dto.Subscription) (entities.Subscription, error) {
+	subEntity, err := parseNewSubscription(ctx, subDto)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	service, err := s.catalogService(ctx, subDto.ServiceID, subDto.ServiceName)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	withService(&subEntity, service)
+
+	return subEntity, nil
+}
+
+// withService points sub at its catalog service, which supplies the price
+// when sub has none.
+func withService(sub *entities.Subscription, service entities.Service) {
+	sub.ServiceID = service.ID
+	sub.ServiceName = service.Name
+
+	if sub.Price == 0 && service.DefaultPrice != nil {
+		sub.Price = *service.DefaultPrice
+	}
+}
+
+// parseNewSubscription validates subDto as a subscription to create, leaving
+// the catalog service unresolved.
+func parseNewSubscription(ctx context.Context, subDto dto.Subscription) (entities.Subscription, error) {
+	if err := restrictToOwner(ctx, &subDto.UserID, auth.RoleUser, auth.RoleAdmin); err != nil {
+		return entities.Subscription{}, err
+	}
+
+	startDateParsed, err := parseDate(subDto.StartDate)
 	if err != nil {
 		logger.Log.Error("Failed to parse start date", "error", err)
 
-		return err
+		return entities.Subscription{}, err
+	}
+
+	billingPeriod, billingInterval, err := parseBillingPeriod(subDto.BillingPeriod, subDto.BillingInterval, entities.BillingMonth)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	currency := entities.DefaultCurrency
+	if subDto.Currency != "" {
+		currency, err = parseCurrency(subDto.Currency)
+		if err != nil {
+			logger.Log.Error("Failed to parse currency", "error", err)
+
+			return entities.Subscription{}, err
+		}
+	}
+
+	splitRule, err := parseSplitRule(subDto.SplitRule, entities.SplitEqual)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	tags, err := normalizeTags(subDto.Tags)
+	if err != nil {
+		return entities.Subscription{}, err
 	}
 
 	subEntity := entities.Subscription{
-		ServiceName: subDto.ServiceName,
-		Price:       subDto.Price,
-		UserID:      subDto.UserID,
-		StartDate:   startDateParsed,
+		Price:           subDto.Price,
+		Currency:        currency,
+		BillingPeriod:   billingPeriod,
+		BillingInterval: billingInterval,
+		UserID:          subDto.UserID,
+		StartDate:       startDateParsed,
+		Status:          entities.StatusActive,
+		SplitRule:       splitRule,
+		Tags:            tags,
+	}
+
+	if subDto.TrialEndDate != "" {
+		trialEndParsed, err := parseDate(subDto.TrialEndDate)
+		if err != nil {
+			logger.Log.Error("Failed to parse trial end date", "error", err)
+
+			return entities.Subscription{}, err
+		}
+
+		if trialEndParsed.Before(startDateParsed) {
+			logger.Log.Error("Trial end date cannot be before start date", "start_date", subDto.StartDate, "trial_end_date", subDto.TrialEndDate)
+
+			return entities.Subscription{}, errors.New("invalid data: trial end date cannot be before start date")
+		}
+
+		subEntity.TrialEndDate = &trialEndParsed
+
+		if trialEndParsed.After(today()) {
+			subEntity.Status = entities.StatusTrial
+		}
 	}
 
 	if subDto.EndDate != "" {
-		endDateParsed, err := time.Parse("01-2006", subDto.EndDate)
+		endDateParsed, err := parsePeriodEnd(subDto.EndDate)
 		if err != nil {
 			logger.Log.Error("Failed to parse end date", "error", err)
 
-			return err
+			return entities.Subscription{}, err
 		}
 
 		if endDateParsed.Before(startDateParsed) {
 			logger.Log.Error("End date cannot be before start date", "start_date", subDto.StartDate, "end_date", subDto.EndDate)
 
-			return errors.New("invalid data: end date cannot be before start date")
+			return entities.Subscription{}, errors.New("invalid data: end date cannot be before start date")
 		}
 
 		subEntity.EndDate = &endDateParsed
 	}
 
-	err = s.repo.CreateSubscription(subEntity)
+	return subEntity, nil
+}
+
+// GetSubscriptionByID returns the subscription; includeDeleted lets admins
+// read one that has been deleted but not yet purged.
+func (s *SubscriptionService) GetSubscriptionByID(ctx context.Context, id string, includeDeleted bool) (dto.Subscription, error) {
+	logger.Log.Info("GetSubscriptionByID called", "id", id, "include_deleted", includeDeleted)
+
+	if err := authorizeIncludeDeleted(ctx, includeDeleted); err != nil {
+		return dto.Subscription{}, err
+	}
+
+	subEntity, err := s.ownedSubscriptionWith(ctx, id, includeDeleted)
 	if err != nil {
-		logger.Log.Error("Failed to create subscription", "error", err)
+		return dto.Subscription{}, err
+	}
 
-		return err
+	logger.Log.Info("Mapping entity to DTO", "service_name", subEntity.ServiceName, "price", subEntity.Price)
+
+	subDTO := toSubscriptionDTO(subEntity)
+
+	logger.Log.Info("Subscription fetched successfully", "id", id, "service_name", subDTO.ServiceName)
+
+	return subDTO, nil
+}
+
+func (s *SubscriptionService) GetSubscriptionsByUserUUID(ctx context.Context, userUUID string) ([]dto.Subscription, error) {
+	logger.Log.Info("GetSubscriptionsByUserUUID called", "user_id", userUUID)
+
+	if err := authorizeOwner(ctx, userUUID); err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.repo.GetSubscriptionsByUserUUID(ctx, userUUID)
+	if err != nil {
+		logger.Log.Error("Failed to get user subscriptions", "error", err)
+		return nil, err
 	}
 
-	logger.Log.Info("Subscription created successfully", "user_id", subDto.UserID, "service_name", subDto.ServiceName)
+	result := toSubscriptionDTOs(subscriptions)
 
-	return nil
+	logger.Log.Info("User subscriptions fetched successfully", "user_id", userUUID, "result_count", len(result))
+
+	return result, nil
 }
 
-func (s *SubscriptionService) GetSubscriptionByUserUUID(userUUID string) (dto.Subscription, error) {
-	logger.Log.Info("GetSubscriptionByUserUUID called", "user_id", userUUID)
+func (s *SubscriptionService) GetSubscriptionFiltered(ctx context.Context, req dto.FilterSubscriptionsRequest) (dto.SubscriptionPage, error) {
+	logger.Log.Info("GetSubscriptionFiltered called", "user_id", req.UserID, "service_name", req.ServiceName, "limit", req.Limit, "sort", req.Sort)
 
-	subEntity, err := s.repo.GetSubscriptionByUserUUID(userUUID)
+	filter, err := parseSubscriptionFilter(req.Subscription, req.Q)
 	if err != nil {
-		logger.Log.Error("Failed to get subscription", "error", err)
-		return dto.Subscription{}, err
+		return dto.SubscriptionPage{}, err
 	}
 
-	logger.Log.Info("Mapping entity to DTO", "service_name", subEntity.ServiceName, "price", subEntity.Price)
+	filter.MonthlyCostMin = req.MonthlyCostMin
+	filter.IncludeDeleted = req.IncludeDeleted
 
-	startDateFormatted := subEntity.StartDate.Format("01-2006")
+	page, err := parsePageRequest(req.Limit, req.Cursor, req.Sort, req.Order, len(filter.SearchTerms) > 0)
+	if err != nil {
+		return dto.SubscriptionPage{}, err
+	}
 
-	subDTO := dto.Subscription{
-		ServiceName: subEntity.ServiceName,
-		Price:       subEntity.Price,
-		UserID:      subEntity.UserID,
-		StartDate:   startDateFormatted,
+	result, err := s.findSubscriptions(ctx, filter, page, req.IncludeTotal)
+	if err != nil {
+		return dto.SubscriptionPage{}, err
 	}
 
-	if subEntity.EndDate != nil {
-		endDateFormatted := subEntity.EndDate.Format("01-2006")
-		subDTO.EndDate = endDateFormatted
+	if len(result.Items) == 0 && page.After == nil {
+		logger.Log.Error("Subscriptions not found", "user_id", req.UserID, "service_name", req.ServiceName)
+
+		return dto.SubscriptionPage{}, errormsgs.NotFound
 	}
 
-	logger.Log.Info("Subscription fetched successfully", "user_id", userUUID, "service_name", subDTO.ServiceName)
+	return result, nil
+}
 
-	return subDTO, nil
+// SearchSubscriptions serves GET /subscriptions. started_after and
+// ended_before are exclusive; active_on matches subscriptions running that day.
+func (s *SubscriptionService) SearchSubscriptions(ctx context.Context, query dto.SubscriptionQuery) (dto.SubscriptionPage, error) {
+	logger.Log.Info("SearchSubscriptions called", "user_id", query.UserID, "service_name_in", query.ServiceNameIn, "limit", query.Limit, "sort", query.Sort)
+
+	filter, err := parseSubscriptionQuery(query)
+	if err != nil {
+		return dto.SubscriptionPage{}, err
+	}
+
+	page, err := parsePageRequest(query.Limit, query.Cursor, query.Sort, query.Order, len(filter.SearchTerms) > 0)
+	if err != nil {
+		return dto.SubscriptionPage{}, err
+	}
+
+	return s.findSubscriptions(ctx, filter, page, query.IncludeTotal)
 }
 
-func (s *SubscriptionService) GetSubscriptionFiltered(subDTO dto.Subscription) ([]dto.Subscription, error) {
-	logger.Log.Info("GetSubscriptionFiltered called", "user_id", subDTO.UserID, "service_name", subDTO.ServiceName)
+func (s *SubscriptionService) findSubscriptions(ctx context.Context, filter entities.SubscriptionFilter, page entities.PageRequest, includeTotal bool) (dto.SubscriptionPage, error) {
+	if err := restrictToOwner(ctx, &filter.UserID, auth.RoleUser, auth.RoleAdmin); err != nil {
+		return dto.SubscriptionPage{}, err
+	}
+
+	if err := authorizeIncludeDeleted(ctx, filter.IncludeDeleted); err != nil {
+		return dto.SubscriptionPage{}, err
+	}
+
+	limit := page.Limit
+
+	// One extra row tells whether another page follows.
+	page.Limit++
+
+	subscriptions, err := s.repo.GetSubscriptionFiltered(ctx, filter, page)
+	if err != nil {
+		logger.Log.Error("Failed to get filtered subscriptions", "error", err)
+		return dto.SubscriptionPage{}, err
+	}
+
+	var result dto.SubscriptionPage
+
+	if uint64(len(subscriptions)) > limit {
+		subscriptions = subscriptions[:limit]
+		result.NextCursor = encodeCursor(page, subscriptions[len(subscriptions)-1])
+	}
+
+	logger.Log.Info("Mapping filtered subscriptions to DTO", "count", len(subscriptions))
+
+	result.Items = toSubscriptionDTOs(subscriptions)
+
+	if includeTotal {
+		total, err := s.repo.CountSubscriptionsFiltered(ctx, filter)
+		if err != nil {
+			logger.Log.Error("Failed to count filtered subscriptions", "error", err)
+
+			return dto.SubscriptionPage{}, err
+		}
+
+		result.Total = &total
+	}
 
+	logger.Log.Info("Filtered subscriptions fetched successfully", "result_count", len(result.Items), "has_more", result.NextCursor != "")
+
+	return result, nil
+}
+
+func parseSubscriptionFilter(subDTO dto.Subscription, q string) (entities.SubscriptionFilter, error) {
 	var startDateParsed, endDateParsed time.Time
 
 	if subDTO.StartDate != "" {
 		logger.Log.Info("Parsing start date", "start_date", subDTO.StartDate)
 
-		parsed, err := time.Parse("01-2006", subDTO.StartDate)
+		parsed, err := parseDate(subDTO.StartDate)
 		if err != nil {
 			logger.Log.Error("Failed to parse start date", "error", err)
-			return nil, err
+			return entities.SubscriptionFilter{}, err
 		}
 
 		startDateParsed = parsed
@@ -116,10 +418,10 @@ func (s *SubscriptionService) GetSubscriptionFiltered(subDTO dto.Subscription) (
 	if subDTO.EndDate != "" {
 		logger.Log.Info("Parsing end date", "end_date", subDTO.EndDate)
 
-		parsed, err := time.Parse("01-2006", subDTO.EndDate)
+		parsed, err := parsePeriodEnd(subDTO.EndDate)
 		if err != nil {
 			logger.Log.Error("Failed to parse end date", "error", err)
-			return nil, err
+			return entities.SubscriptionFilter{}, err
 		}
 
 		endDateParsed = parsed
@@ -128,103 +430,484 @@ func (s *SubscriptionService) GetSubscriptionFiltered(subDTO dto.Subscription) (
 	if !startDateParsed.IsZero() && !endDateParsed.IsZero() && endDateParsed.Before(startDateParsed) {
 		logger.Log.Error("End date cannot be before start date", "start_date", subDTO.StartDate, "end_date", subDTO.EndDate)
 
-		return nil, errors.New("invalid data: end date cannot be before start date")
+		return entities.SubscriptionFilter{}, errors.New("invalid data: end date cannot be before start date")
 	}
 
 	logger.Log.Info("Building filter entity", "user_id", subDTO.UserID, "service_name", subDTO.ServiceName, "price", subDTO.Price)
-	
-	subEntity := entities.Subscription{
-		UserID:      subDTO.UserID,
-		Price:       subDTO.Price,
-		ServiceName: subDTO.ServiceName,
-		StartDate:   startDateParsed,
-		EndDate:     &endDateParsed,
+
+	billingPeriod, _, err := parseBillingPeriod(subDTO.BillingPeriod, 0, "")
+	if err != nil {
+		return entities.SubscriptionFilter{}, err
 	}
 
-	subscriptions, err := s.repo.GetSubscriptionFiltered(subEntity)
+	currency, err := parseOptionalCurrency(subDTO.Currency)
 	if err != nil {
-		logger.Log.Error("Failed to get filtered subscriptions", "error", err)
-		return nil, err
+		return entities.SubscriptionFilter{}, err
 	}
 
-	logger.Log.Info("Mapping filtered subscriptions to DTO", "count", len(subscriptions))
+	tags, err := normalizeTags(subDTO.Tags)
+	if err != nil {
+		return entities.SubscriptionFilter{}, err
+	}
 
-	result := make([]dto.Subscription, 0)
+	filter := entities.SubscriptionFilter{
+		UserID:        subDTO.UserID,
+		SearchTerms:   searchTerms(q),
+		Tags:          tags,
+		Currency:      currency,
+		BillingPeriod: billingPeriod,
+	}
 
-	for _, sub := range subscriptions {
-		startDateFormatted := sub.StartDate.Format("01-2006")
-		endDateFormatted := ""
-		if sub.EndDate != nil {
-			endDateFormatted = sub.EndDate.Format("01-2006")
+	if subDTO.Price != 0 {
+		filter.PriceMin = &subDTO.Price
+	}
+
+	if subDTO.ServiceName != "" {
+		filter.ServiceNames = []string{subDTO.ServiceName}
+	}
+
+	if !startDateParsed.IsZero() {
+		filter.StartDateFrom = &startDateParsed
+	}
+
+	if !endDateParsed.IsZero() {
+		filter.EndDateTo = &endDateParsed
+	}
+
+	return filter, nil
+}
+
+func parseSubscriptionQuery(query dto.SubscriptionQuery) (entities.SubscriptionFilter, error) {
+	billingPeriod, _, err := parseBillingPeriod(query.BillingPeriod, 0, "")
+	if err != nil {
+		return entities.SubscriptionFilter{}, err
+	}
+
+	currency, err := parseOptionalCurrency(query.Currency)
+	if err != nil {
+		return entities.SubscriptionFilter{}, err
+	}
+
+	if query.PriceMin != nil && query.PriceMax != nil && *query.PriceMin > *query.PriceMax {
+		logger.Log.Error("price_min is greater than price_max", "price_min", *query.PriceMin, "price_max", *query.PriceMax)
+
+		return entities.SubscriptionFilter{}, errors.New("invalid data: price_min cannot be greater than price_max")
+	}
+
+	tags, err := normalizeTags(query.Tags)
+	if err != nil {
+		return entities.SubscriptionFilter{}, err
+	}
+
+	filter := entities.SubscriptionFilter{
+		UserID:         query.UserID,
+		ServiceNames:   query.ServiceNameIn,
+		SearchTerms:    searchTerms(query.Q),
+		Tags:           tags,
+		Currency:       currency,
+		BillingPeriod:  billingPeriod,
+		PriceMin:       query.PriceMin,
+		PriceMax:       query.PriceMax,
+		IncludeDeleted: query.IncludeDeleted,
+	}
+
+	dates := []struct {
+		name, value string
+		days        int
+		target      **time.Time
+	}{
+		{"active_on", query.ActiveOn, 0, &filter.ActiveOn},
+		{"started_after", query.StartedAfter, 1, &filter.StartDateFrom},
+		{"ended_before", query.EndedBefore, -1, &filter.EndDateTo},
+	}
+
+	for _, date := range dates {
+		if date.value == "" {
+			continue
 		}
 
-		result = append(result, dto.Subscription{
-			ServiceName: sub.ServiceName,
-			Price:       sub.Price,
-			UserID:      sub.UserID,
-			StartDate:   startDateFormatted,
-			EndDate:     endDateFormatted,
-		})
+		parsed, err := parseDate(date.value)
+		if err != nil {
+			logger.Log.Error("Failed to parse date parameter", "parameter", date.name, "error", err)
+
+			return entities.SubscriptionFilter{}, fmt.Errorf("%s: %w", date.name, err)
+		}
+
+		parsed = parsed.AddDate(0, 0, date.days)
+		*date.target = &parsed
 	}
 
-	logger.Log.Info("Filtered subscriptions fetched successfully", "result_count", len(result))
+	return filter, nil
+}
 
-	return result, nil
+// UpdateSubscriptionByID replaces the subscription with subDTO. It fails with
+// PreconditionFailed when ifMatch is given and the subscription has changed
+// since it was read.
+func (s *SubscriptionService) UpdateSubscriptionByID(ctx context.Context, subDTO dto.UpdateSubscription, id, ifMatch string) error {
+	logger.Log.Info("UpdateSubscriptionByID called", "id", id)
+
+	current, err := s.ownedSubscription(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	expected, err := expectVersions(ifMatch, dto.SubscriptionETag(toSubscriptionDTO(current)), current)
+	if err != nil {
+		return err
+	}
+
+	subEntity, err := s.replacement(ctx, current, subDTO)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.ReplaceSubscriptions(ctx, []entities.Subscription{subEntity}, expected)
+	if err != nil {
+		logger.Log.Error("Failed to update subscription", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscription updated successfully", "id", id)
+
+	return nil
 }
 
-func (s *SubscriptionService) UpdateSubscriptionByUserUUID(subDTO dto.UpdateSubscription, userUUID string) error {
-	logger.Log.Info("UpdateSubscriptionByUserUUID called", "user_id", userUUID)
+// PatchSubscriptionByID merges patch into the subscription.
+func (s *SubscriptionService) PatchSubscriptionByID(ctx context.Context, patch dto.SubscriptionPatch, id, ifMatch string) error {
+	logger.Log.Info("PatchSubscriptionByID called", "id", id)
 
-	subEntity := entities.Subscription{
-		ServiceName: subDTO.ServiceName,
-		Price:       subDTO.Price,
-		UserID:      userUUID,
+	current, err := s.ownedSubscription(ctx, id)
+	if err != nil {
+		return err
 	}
 
-	if subDTO.StartDate != "" {
-		startDateParsed, err := time.Parse("01-2006", subDTO.StartDate)
+	expected, err := expectVersions(ifMatch, dto.SubscriptionETag(toSubscriptionDTO(current)), current)
+	if err != nil {
+		return err
+	}
+
+	update := toUpdateSubscription(current)
+	patch.Apply(&update)
+
+	subEntity, err := s.replacement(ctx, current, update)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.ReplaceSubscriptions(ctx, []entities.Subscription{subEntity}, expected)
+	if err != nil {
+		logger.Log.Error("Failed to patch subscription", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscription patched successfully", "id", id)
+
+	return nil
+}
+
+// PatchSubscriptionsByUserUUID merges patch into every subscription of the
+// user at once. It fails with PreconditionFailed when ifMatch is given and any
+// of them has changed since they were read.
+func (s *SubscriptionService) PatchSubscriptionsByUserUUID(ctx context.Context, patch dto.SubscriptionPatch, userUUID, ifMatch string) error {
+	logger.Log.Info("PatchSubscriptionsByUserUUID called", "user_id", userUUID)
+
+	if err := authorizeOwner(ctx, userUUID); err != nil {
+		return err
+	}
+
+	if patch.SplitRule.Set {
+		logger.Log.Error("Split rule change for all user subscriptions", "user_id", userUUID)
+
+		return errors.New("invalid data: split_rule can only be changed per subscription")
+	}
+
+	current, err := s.repo.GetSubscriptionsByUserUUID(ctx, userUUID)
+	if err != nil {
+		logger.Log.Error("Failed to get user subscriptions", "error", err)
+
+		return err
+	}
+
+	expected, err := expectVersions(ifMatch, dto.CollectionETag(toSubscriptionDTOs(current)), current...)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := make([]entities.Subscription, 0, len(current))
+
+	for _, sub := range current {
+		update := toUpdateSubscription(sub)
+		patch.Apply(&update)
+
+		subEntity, err := s.replacement(ctx, sub, update)
 		if err != nil {
-			logger.Log.Error("Failed to parse start date", "error", err)
+			return err
+		}
+
+		subscriptions = append(subscriptions, subEntity)
+	}
+
+	err = s.repo.ReplaceSubscriptions(ctx, subscriptions, expected)
+	if err != nil {
+		logger.Log.Error("Failed to patch subscriptions", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscriptions patched successfully", "user_id", userUUID, "count", len(subscriptions))
 
+	return nil
+}
+
+// ReplaceSubscriptionsByUserUUID replaces every subscription of the user with
+// update at once. Each keeps its own split_rule. It fails with
+// PreconditionFailed when ifMatch is given and any of them has changed since
+// they were read.
+func (s *SubscriptionService) ReplaceSubscriptionsByUserUUID(ctx context.Context, update dto.UpdateSubscription, userUUID, ifMatch string) error {
+	logger.Log.Info("ReplaceSubscriptionsByUserUUID called", "user_id", userUUID)
+
+	if err := authorizeOwner(ctx, userUUID); err != nil {
+		return err
+	}
+
+	if update.SplitRule != "" {
+		logger.Log.Error("Split rule change for all user subscriptions", "user_id", userUUID)
+
+		return errors.New("invalid data: split_rule can only be changed per subscription")
+	}
+
+	current, err := s.repo.GetSubscriptionsByUserUUID(ctx, userUUID)
+	if err != nil {
+		logger.Log.Error("Failed to get user subscriptions", "error", err)
+
+		return err
+	}
+
+	expected, err := expectVersions(ifMatch, dto.CollectionETag(toSubscriptionDTOs(current)), current...)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := make([]entities.Subscription, 0, len(current))
+
+	for _, sub := range current {
+		subUpdate := update
+		subUpdate.SplitRule = string(sub.SplitRule)
+
+		subEntity, err := s.replacement(ctx, sub, subUpdate)
+		if err != nil {
 			return err
 		}
 
-		subEntity.StartDate = startDateParsed
+		subscriptions = append(subscriptions, subEntity)
 	}
 
-	if subDTO.EndDate != "" {
-		endDateParsed, err := time.Parse("01-2006", subDTO.EndDate)
+	err = s.repo.ReplaceSubscriptions(ctx, subscriptions, expected)
+	if err != nil {
+		logger.Log.Error("Failed to replace subscriptions", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscriptions replaced successfully", "user_id", userUUID, "count", len(subscriptions))
+
+	return nil
+}
+
+// toUpdateSubscription is the full state of sub as PUT takes it.
+func toUpdateSubscription(sub entities.Subscription) dto.UpdateSubscription {
+	update := dto.UpdateSubscription{
+		ServiceID:       sub.ServiceID,
+		ServiceName:     sub.ServiceName,
+		Price:           sub.Price,
+		Currency:        string(sub.Currency),
+		BillingPeriod:   string(sub.BillingPeriod),
+		BillingInterval: sub.BillingInterval,
+		StartDate:       formatDate(sub.StartDate),
+		SplitRule:       string(sub.SplitRule),
+		Tags:            sub.Tags,
+	}
+
+	if sub.EndDate != nil {
+		update.EndDate = formatDate(*sub.EndDate)
+	}
+
+	return update
+}
+
+// replacement validates update as the new full state of current. A price
+// other than the current one is recorded from price_effective_date, or the
+// current month when it is omitted.
+func (s *SubscriptionService) replacement(ctx context.Context, current entities.Subscription, update dto.UpdateSubscription) (entities.Subscription, error) {
+	if update.StartDate == "" {
+		return entities.Subscription{}, errors.New("invalid data: start_date is required")
+	}
+
+	if update.ServiceID == "" && update.ServiceName == "" {
+		return entities.Subscription{}, errors.New("invalid data: service_name is required")
+	}
+
+	if update.Price < 0 {
+		return entities.Subscription{}, errors.New("invalid data: price cannot be negative")
+	}
+
+	startDate, err := parseDate(update.StartDate)
+	if err != nil {
+		logger.Log.Error("Failed to parse start date", "error", err)
+
+		return entities.Subscription{}, err
+	}
+
+	billingPeriod, billingInterval, err := parseBillingPeriod(update.BillingPeriod, update.BillingInterval, entities.BillingMonth)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	currency := entities.DefaultCurrency
+	if update.Currency != "" {
+		currency, err = parseCurrency(update.Currency)
+		if err != nil {
+			logger.Log.Error("Failed to parse currency", "error", err)
+
+			return entities.Subscription{}, err
+		}
+	}
+
+	splitRule, err := parseSplitRule(update.SplitRule, entities.SplitEqual)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	tags, err := normalizeTags(update.Tags)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
+
+	subEntity := entities.Subscription{
+		ID:              current.ID,
+		ServiceID:       current.ServiceID,
+		ServiceName:     current.ServiceName,
+		Price:           update.Price,
+		Currency:        currency,
+		BillingPeriod:   billingPeriod,
+		BillingInterval: billingInterval,
+		UserID:          current.UserID,
+		StartDate:       startDate,
+		SplitRule:       splitRule,
+		Tags:            tags,
+	}
+
+	if update.EndDate != "" {
+		endDate, err := parsePeriodEnd(update.EndDate)
 		if err != nil {
 			logger.Log.Error("Failed to parse end date", "error", err)
 
-			return err
+			return entities.Subscription{}, err
 		}
 
-		subEntity.EndDate = &endDateParsed
+		if endDate.Before(startDate) {
+			logger.Log.Error("End date cannot be before start date", "start_date", update.StartDate, "end_date", update.EndDate)
+
+			return entities.Subscription{}, errors.New("invalid data: end date cannot be before start date")
+		}
+
+		subEntity.EndDate = &endDate
 	}
 
-	if subEntity.EndDate != nil && subEntity.StartDate.After(*subEntity.EndDate) {
-		logger.Log.Error("End date cannot be before start date", "start_date", subDTO.StartDate, "end_date", subDTO.EndDate)
+	switch {
+	case update.PriceEffectiveDate != "":
+		effectiveDate, err := parseDate(update.PriceEffectiveDate)
+		if err != nil {
+			logger.Log.Error("Failed to parse price effective date", "error", err)
+
+			return entities.Subscription{}, err
+		}
+
+		subEntity.PriceEffectiveMonth = effectiveDate
+	case update.Price != current.Price:
+		subEntity.PriceEffectiveMonth = today()
+	}
+
+	if update.ServiceID != current.ServiceID || update.ServiceName != current.ServiceName {
+		service, err := s.catalogService(ctx, update.ServiceID, update.ServiceName)
+		if err != nil {
+			return entities.Subscription{}, err
+		}
+
+		subEntity.ServiceID = service.ID
+		subEntity.ServiceName = service.Name
+	}
 
-		return errors.New("invalid data: end date cannot be before start date")
+	if subEntity.SplitRule != current.SplitRule || subEntity.Price != current.Price {
+		if err := s.checkMemberShares(ctx, subEntity); err != nil {
+			return entities.Subscription{}, err
+		}
+	}
+
+	return subEntity, nil
+}
+
+// catalogService returns the catalog entry by id when given, otherwise by
+// resolving the free-text name through the alias list.
+func (s *SubscriptionService) catalogService(ctx context.Context, id, name string) (entities.Service, error) {
+	if id == "" {
+		return resolveService(ctx, s.services, name)
+	}
+
+	service, err := s.services.GetServiceByID(ctx, id)
+	if errormsgs.IsNotFound(err) {
+		return entities.Service{}, fmt.Errorf("invalid data: unknown service_id %q", id)
 	}
 
-	err := s.repo.UpdateSubscriptionByUserUUID(subEntity)
 	if err != nil {
-		logger.Log.Error("Failed to update subscription", "error", err)
+		logger.Log.Error("Failed to get service", "error", err)
+
+		return entities.Service{}, err
+	}
+
+	return service, nil
+}
+
+func (s *SubscriptionService) DeleteSubscriptionByID(ctx context.Context, id, ifMatch string) error {
+	logger.Log.Info("DeleteSubscriptionByID called", "id", id)
 
+	current, err := s.ownedSubscription(ctx, id)
+	if err != nil {
 		return err
 	}
 
-	logger.Log.Info("Subscription updated successfully", "user_id", userUUID)
+	expected, err := expectVersions(ifMatch, dto.SubscriptionETag(toSubscriptionDTO(current)), current)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteSubscriptionByID(ctx, id, expected)
+	if err != nil {
+		logger.Log.Error("Failed to delete subscription", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Subscription deleted successfully", "id", id)
 
 	return nil
 }
 
-func (s *SubscriptionService) DeleteSubscriptionByUserUUID(userUUID string) error {
+func (s *SubscriptionService) DeleteSubscriptionByUserUUID(ctx context.Context, userUUID, ifMatch string) error {
 	logger.Log.Info("DeleteSubscriptionByUserUUID called", "user_id", userUUID)
 
-	err := s.repo.DeleteSubscriptionByUserUUID(userUUID)
+	if err := authorizeOwner(ctx, userUUID); err != nil {
+		return err
+	}
+
+	expected, err := s.userSubscriptionsVersions(ctx, userUUID, ifMatch)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteSubscriptionByUserUUID(ctx, userUUID, expected)
 	if err != nil {
 		logger.Log.Error("Failed to delete subscription", "error", err)
 
@@ -236,40 +919,58 @@ func (s *SubscriptionService) DeleteSubscriptionByUserUUID(userUUID string) erro
 	return nil
 }
 
-func (s *SubscriptionService) SumSubscriptions(req dto.SumSubscriptionsRequest) (int, error) {
-	logger.Log.Info("SumSubscriptions called", "user_id", req.UserID, "service_name", req.ServiceName)
+// RestoreSubscription undoes the deletion of a subscription that has not been
+// purged yet.
+func (s *SubscriptionService) RestoreSubscription(ctx context.Context, id string) (dto.Subscription, error) {
+	logger.Log.Info("RestoreSubscription called", "id", id)
 
-	var startDate, endDate *time.Time
+	sub, err := s.ownedSubscriptionWith(ctx, id, true)
+	if err != nil {
+		return dto.Subscription{}, err
+	}
 
-	if req.StartPeriod != "" {
-		t, err := time.Parse("01-2006", req.StartPeriod)
-		if err != nil {
-			logger.Log.Error("Failed to parse start period", "error", err)
+	if sub.DeletedAt == nil {
+		logger.Log.Error("Subscription is not deleted", "id", id)
 
-			return 0, err
-		}
+		return dto.Subscription{}, fmt.Errorf("%w: subscription is not deleted", errormsgs.Conflict)
+	}
+
+	if err := s.repo.RestoreSubscription(ctx, id); err != nil {
+		logger.Log.Error("Failed to restore subscription", "error", err)
 
-		startDate = &t
+		return dto.Subscription{}, err
 	}
 
-	if req.EndPeriod != "" {
-		t, err := time.Parse("01-2006", req.EndPeriod)
-		if err != nil {
-			logger.Log.Error("Failed to parse end period", "error", err)
+	logger.Log.Info("Subscription restored successfully", "id", id)
 
-			return 0, err
-		}
-		endDate = &t
+	return s.GetSubscriptionByID(ctx, id, false)
+}
+
+func (s *SubscriptionService) GetPriceHistory(ctx context.Context, id string) ([]dto.PriceChange, error) {
+	logger.Log.Info("GetPriceHistory called", "id", id)
+
+	if _, err := s.ownedSubscription(ctx, id); err != nil {
+		return nil, err
 	}
 
-	total, err := s.repo.SumSubscriptions(req.UserID, req.ServiceName, startDate, endDate)
+	changes, err := s.repo.GetPriceChanges(ctx, id)
 	if err != nil {
-		logger.Log.Error("Failed to sum subscriptions", "error", err)
+		logger.Log.Error("Failed to get price history", "error", err)
 
-		return 0, err
+		return nil, err
+	}
+
+	result := make([]dto.PriceChange, 0, len(changes))
+
+	for _, change := range changes {
+		result = append(result, dto.PriceChange{
+			EffectiveMonth: formatDate(change.EffectiveMonth),
+			Price:          change.Price,
+			CreatedAt:      change.CreatedAt.UTC().Format(time.RFC3339),
+		})
 	}
 
-	logger.Log.Info("SumSubscriptions completed", "user_id", req.UserID, "service_name", req.ServiceName, "total", total)
+	logger.Log.Info("Price history fetched successfully", "id", id, "count", len(result))
 
-	return total, nil
+	return result, nil
 }
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const maxTagLength = 50
+
+// normalizeTags lower-cases tags and collapses their whitespace, dropping
+// empty and repeated ones.
+func normalizeTags(tags []string) ([]string, error) {
+	var result []string
+
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+		if tag == "" || slices.Contains(result, tag) {
+			continue
+		}
+
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			logger.Log.Error("Tag too long", "tag", tag)
+
+			return nil, fmt.Errorf("invalid data: tag %q is longer than %d characters", tag, maxTagLength)
+		}
+
+		result = append(result, tag)
+	}
+
+	return result, nil
+}
+
+func (s *SubscriptionService) GetTags(ctx context.Context) ([]dto.Tag, error) {
+	logger.Log.Info("GetTags called")
+
+	if _, err := requireRole(ctx, auth.RoleUser, auth.RoleAnalyst, auth.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.repo.GetTags(ctx)
+	if err != nil {
+		logger.Log.Error("Failed to get tags", "error", err)
+
+		return nil, err
+	}
+
+	result := make([]dto.Tag, 0, len(tags))
+
+	for _, tag := range tags {
+		result = append(result, dto.Tag{Name: tag.Name, Subscriptions: tag.Subscriptions})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// APIKeyHeader carries API keys.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator accepts keys stored hashed in the APIKeyRepo.
+type APIKeyAuthenticator struct {
+	repo ports.APIKeyRepo
+}
+
+func NewAPIKeyAuthenticator(repo ports.APIKeyRepo) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{repo: repo}
+}
+
+// HashAPIKey returns the digest api_keys stores for key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	apiKey, err := a.repo.GetAPIKeyByHash(r.Context(), HashAPIKey(key))
+	if err != nil {
+		if errormsgs.IsNotFound(err) {
+			return Principal{}, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+		}
+
+		return Principal{}, err
+	}
+
+	if !apiKey.IsUsable(time.Now()) {
+		logger.Log.Error("Expired or revoked API key used", "id", apiKey.ID)
+
+		return Principal{}, fmt.Errorf("%w: API key expired or revoked", ErrInvalidCredentials)
+	}
+
+	if err := a.repo.TouchAPIKey(r.Context(), apiKey.ID); err != nil {
+		logger.Log.Error("Failed to record API key use", "id", apiKey.ID, "error", err)
+	}
+
+	return Principal{
+		Subject:        apiKey.ID,
+		UserID:         apiKey.UserID,
+		Role:           Role(apiKey.Role),
+		OrganizationID: apiKey.OrganizationID,
+		Scopes:         apiKey.Scopes,
+		Method:         MethodAPIKey,
+	}, nil
+}
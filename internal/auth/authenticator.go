@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoCredentials means the request carries no credentials the
+	// authenticator understands, so the next one should be tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials means the credentials were recognised but rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authenticator resolves the principal of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Principal, error)
+}
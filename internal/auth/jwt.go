@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/agl/online_subs/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// tokenClaims are the claims read from bearer tokens. The subject is the
+// user ID, role defaults to user, and scopes come from the space-separated
+// scope claim or the scp array.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	OrganizationID string   `json:"org_id"`
+	Role           Role     `json:"role"`
+	Scope          string   `json:"scope"`
+	Scp            []string `json:"scp"`
+}
+
+// JWTAuthenticator accepts bearer tokens signed with an HMAC secret or a key
+// of a JWKS file.
+type JWTAuthenticator struct {
+	keyFunc jwt.Keyfunc
+	parser  *jwt.Parser
+}
+
+// NewJWTAuthenticator configures bearer tokens from the environment:
+// JWT_JWKS_FILE or JWT_HMAC_SECRET select the keys, and JWT_ISSUER and
+// JWT_AUDIENCE, when set, must match the iss and aud claims. It returns nil
+// when no keys are configured.
+func NewJWTAuthenticator() (*JWTAuthenticator, error) {
+	jwksFile := os.Getenv("JWT_JWKS_FILE")
+	secret := os.Getenv("JWT_HMAC_SECRET")
+
+	options := []jwt.ParserOption{jwt.WithExpirationRequired()}
+
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	switch {
+	case jwksFile != "":
+		keys, err := loadJWKS(jwksFile)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Log.Info("JWT keys loaded", "file", jwksFile, "count", len(keys))
+
+		options = append(options, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "PS384", "PS512"}))
+
+		return &JWTAuthenticator{keyFunc: keys.keyFunc, parser: jwt.NewParser(options...)}, nil
+	case secret != "":
+		options = append(options, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+
+		return &JWTAuthenticator{
+			keyFunc: func(*jwt.Token) (any, error) { return []byte(secret), nil },
+			parser:  jwt.NewParser(options...),
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	header := r.Header.Get("Authorization")
+
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	var claims tokenClaims
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &claims, a.keyFunc); err != nil {
+		logger.Log.Error("Bearer token rejected", "error", err)
+
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	if claims.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	if claims.OrganizationID != "" && !uuidPattern.MatchString(claims.OrganizationID) {
+		return Principal{}, fmt.Errorf("%w: org_id is not a UUID", ErrInvalidCredentials)
+	}
+
+	role := claims.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	if !role.IsValid() {
+		return Principal{}, fmt.Errorf("%w: unknown role %q", ErrInvalidCredentials, role)
+	}
+
+	scopes := claims.Scp
+	if claims.Scope != "" {
+		scopes = strings.Fields(claims.Scope)
+	}
+
+	return Principal{
+		Subject:        claims.Subject,
+		UserID:         claims.Subject,
+		Role:           role,
+		OrganizationID: claims.OrganizationID,
+		Scopes:         scopes,
+		Method:         MethodJWT,
+	}, nil
+}
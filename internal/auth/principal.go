@@ -0,0 +1,48 @@
+// Package auth authenticates requests and carries the authenticated principal.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Scopes granted to principals. Read covers safe methods, write the rest.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Methods a principal can authenticate with.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is who a request acts as. Subject is the API key ID or the JWT
+// subject, and UserID the user whose subscriptions a RoleUser principal may
+// touch. OrganizationID is empty when the credentials do not name one.
+type Principal struct {
+	Subject        string
+	UserID         string
+	Role           Role
+	OrganizationID string
+	Scopes         []string
+	Method         string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal of ctx and whether there is one.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+
+	return principal, ok
+}
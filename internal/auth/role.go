@@ -0,0 +1,20 @@
+package auth
+
+// Role decides what a principal may do: users act on their own
+// subscriptions, analysts read aggregates and admins do everything.
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleAnalyst Role = "analyst"
+	RoleAdmin   Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleAnalyst, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,38 @@
+package entities
+
+import "time"
+
+type AggregationDimension string
+
+const (
+	DimensionServiceName AggregationDimension = "service_name"
+	DimensionUserID      AggregationDimension = "user_id"
+	DimensionMonth       AggregationDimension = "month"
+	DimensionTag         AggregationDimension = "tag"
+)
+
+type AggregationMetric string
+
+const (
+	MetricTotal   AggregationMetric = "total"
+	MetricCount   AggregationMetric = "count"
+	MetricAverage AggregationMetric = "average"
+)
+
+type AggregationSpec struct {
+	CostFilter
+	GroupBy    []AggregationDimension
+	SortBy     AggregationMetric
+	Descending bool
+	Limit      uint64
+}
+
+type AggregationRow struct {
+	ServiceName string
+	UserID      string
+	Month       *time.Time
+	Tag         *string
+	Total       float64
+	Count       int
+	Average     float64
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+// APIKey grants its Scopes within an organisation until it expires or is
+// revoked, acting as Role and, for the user role, as UserID. Only the hash
+// of the key is kept.
+type APIKey struct {
+	ID             string
+	OrganizationID string
+	Name           string
+	Role           string
+	UserID         string
+	Scopes         []string
+	ExpiresAt      *time.Time
+	RevokedAt      *time.Time
+}
+
+// IsUsable reports whether the key may authenticate requests at now.
+func (k APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate       AuditAction = "create"
+	AuditUpdate       AuditAction = "update"
+	AuditStatusChange AuditAction = "status_change"
+	AuditDelete       AuditAction = "delete"
+	AuditRestore      AuditAction = "restore"
+)
+
+// AuditEntry records a change of a subscription owned by UserID. Before is
+// nil for creations and After for deletions.
+type AuditEntry struct {
+	ID             int64
+	SubscriptionID string
+	UserID         string
+	Actor          string
+	Action         AuditAction
+	Before         json.RawMessage
+	After          json.RawMessage
+	RequestID      string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,32 @@
+package entities
+
+// SubscriptionBatch is a set of subscription writes committed together.
+type SubscriptionBatch struct {
+	// Services are added to the catalog before the creations, which name
+	// them by ServiceName and leave ServiceID empty.
+	Services []Service
+
+	Create  []Subscription
+	Replace []Subscription
+	Delete  []string
+
+	// Expected holds the version of every replaced and deleted subscription.
+	Expected Versions
+}
+
+// Only narrows v to ids, keeping a nil Versions nil.
+func (v Versions) Only(ids []string) Versions {
+	if v == nil {
+		return nil
+	}
+
+	only := make(Versions, len(ids))
+
+	for _, id := range ids {
+		if version, ok := v[id]; ok {
+			only[id] = version
+		}
+	}
+
+	return only
+}
@@ -0,0 +1,41 @@
+package entities
+
+type BillingPeriod string
+
+const (
+	BillingWeek    BillingPeriod = "week"
+	BillingMonth   BillingPeriod = "month"
+	BillingQuarter BillingPeriod = "quarter"
+	BillingYear    BillingPeriod = "year"
+)
+
+const weeksPerMonth = 52.0 / 12
+
+func (p BillingPeriod) IsValid() bool {
+	switch p {
+	case BillingWeek, BillingMonth, BillingQuarter, BillingYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// MonthlyCost normalises a price charged every interval periods to a monthly cost.
+func (p BillingPeriod) MonthlyCost(price, interval int) float64 {
+	if interval <= 0 {
+		interval = 1
+	}
+
+	cost := float64(price) / float64(interval)
+
+	switch p {
+	case BillingWeek:
+		return cost * weeksPerMonth
+	case BillingQuarter:
+		return cost / 3
+	case BillingYear:
+		return cost / 12
+	default:
+		return cost
+	}
+}
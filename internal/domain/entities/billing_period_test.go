@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBillingPeriodMonthlyCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		period   BillingPeriod
+		price    int
+		interval int
+		want     float64
+	}{
+		{"month", BillingMonth, 300, 1, 300},
+		{"every two months", BillingMonth, 300, 2, 150},
+		{"week", BillingWeek, 120, 1, 520},
+		{"every two weeks", BillingWeek, 120, 2, 260},
+		{"quarter", BillingQuarter, 900, 1, 300},
+		{"every two quarters", BillingQuarter, 900, 2, 150},
+		{"year", BillingYear, 1200, 1, 100},
+		{"every two years", BillingYear, 2400, 2, 100},
+		{"zero interval counts as one", BillingYear, 1200, 0, 100},
+		{"negative interval counts as one", BillingQuarter, 900, -3, 300},
+		{"unknown period is monthly", BillingPeriod(""), 300, 1, 300},
+		{"free", BillingYear, 0, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.period.MonthlyCost(tt.price, tt.interval)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MonthlyCost(%d, %d) = %v, want %v", tt.price, tt.interval, got, tt.want)
+			}
+		})
+	}
+}
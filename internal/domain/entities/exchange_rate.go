@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+type ExchangeRate struct {
+	Date time.Time
+	From Currency
+	To   Currency
+	Rate float64
+}
+
+type MissingExchangeRate struct {
+	From  Currency
+	To    Currency
+	Month time.Time
+}
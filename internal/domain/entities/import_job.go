@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportRowError is why the CSV row on Line was rejected.
+type ImportRowError struct {
+	Line  int
+	Error string
+}
+
+// ImportJob tracks a CSV import of subscriptions. A dry run validates the
+// rows without writing any. RowErrors may be capped; InvalidRows counts them
+// all.
+type ImportJob struct {
+	ID           string
+	CreatedBy    string
+	Status       ImportStatus
+	DryRun       bool
+	TotalRows    int
+	ValidRows    int
+	InvalidRows  int
+	ImportedRows int
+	RowErrors    []ImportRowError
+	Error        string
+	CreatedAt    time.Time
+	FinishedAt   *time.Time
+}
@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+// SplitRule decides how the cost of a shared subscription is divided between
+// its owner and members. The owner pays whatever the members do not.
+type SplitRule string
+
+const (
+	SplitEqual   SplitRule = "equal"
+	SplitFixed   SplitRule = "fixed"
+	SplitPercent SplitRule = "percent"
+)
+
+func (r SplitRule) IsValid() bool {
+	switch r {
+	case SplitEqual, SplitFixed, SplitPercent:
+		return true
+	default:
+		return false
+	}
+}
+
+// SubscriptionMember is a user sharing a subscription from JoinedOn until the
+// day before LeftOn. Share is an amount per billing period for fixed splits
+// and a percentage for percent splits; equal splits ignore it. A member pays
+// for the months they belong to on the last day of the month.
+type SubscriptionMember struct {
+	SubscriptionID string
+	UserID         string
+	Share          *float64
+	JoinedOn       time.Time
+	LeftOn         *time.Time
+}
@@ -0,0 +1,36 @@
+package entities
+
+type SortField string
+
+const (
+	SortByPrice       SortField = "price"
+	SortByStartDate   SortField = "start_date"
+	SortByServiceName SortField = "service_name"
+	SortByRelevance   SortField = "relevance"
+)
+
+func (f SortField) IsValid() bool {
+	switch f {
+	case SortByPrice, SortByStartDate, SortByServiceName, SortByRelevance:
+		return true
+	}
+
+	return false
+}
+
+// PageCursor is the sort value and id of the last row of the previous page.
+// Value holds an int for price, a time.Time for start_date, a string for
+// service_name and a float64 for relevance.
+type PageCursor struct {
+	Value any
+	ID    string
+}
+
+// PageRequest orders rows by SortBy and id, returning at most Limit rows
+// after the cursor. A zero Limit returns every row.
+type PageRequest struct {
+	SortBy     SortField
+	Descending bool
+	Limit      uint64
+	After      *PageCursor
+}
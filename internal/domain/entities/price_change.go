@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// PriceChange is the price of a subscription from EffectiveMonth until the
+// next change.
+type PriceChange struct {
+	SubscriptionID string
+	EffectiveMonth time.Time
+	Price          int
+	CreatedAt      time.Time
+}
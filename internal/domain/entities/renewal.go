@@ -0,0 +1,66 @@
+package entities
+
+import "time"
+
+// monthsPerPeriod returns the length of the billing period in months, or 0 for weekly billing.
+func (p BillingPeriod) monthsPerPeriod() int {
+	switch p {
+	case BillingWeek:
+		return 0
+	case BillingQuarter:
+		return 3
+	case BillingYear:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// AddMonthsClamped adds months to t keeping its day of month, clamped to the
+// last day of the resulting month (Jan 31 + 1 month is Feb 28 or 29).
+func AddMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+// NextRenewal returns the first billing date on or after from, anchored to the
+// start date's day of month. It returns nil when the subscription ends first.
+func (s Subscription) NextRenewal(from time.Time) *time.Time {
+	interval := max(s.BillingInterval, 1)
+	start := truncateToDay(s.StartDate)
+	from = truncateToDay(from)
+
+	var renewal time.Time
+
+	switch {
+	case !from.After(start):
+		renewal = start
+	case s.BillingPeriod.monthsPerPeriod() == 0:
+		step := 7 * interval
+		days := int(from.Sub(start).Hours() / 24)
+		periods := (days + step - 1) / step
+		renewal = start.AddDate(0, 0, periods*step)
+	default:
+		step := s.BillingPeriod.monthsPerPeriod() * interval
+		months := (from.Year()-start.Year())*12 + int(from.Month()-start.Month())
+		periods := months / step
+
+		renewal = AddMonthsClamped(start, periods*step)
+		for renewal.Before(from) {
+			periods++
+			renewal = AddMonthsClamped(start, periods*step)
+		}
+	}
+
+	if s.EndDate != nil && renewal.After(truncateToDay(*s.EndDate)) {
+		return nil
+	}
+
+	return &renewal
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,163 @@
+package entities
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAddMonthsClamped(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{"same day", date(2025, time.March, 15), 1, date(2025, time.April, 15)},
+		{"31st into February", date(2025, time.January, 31), 1, date(2025, time.February, 28)},
+		{"31st into leap February", date(2024, time.January, 31), 1, date(2024, time.February, 29)},
+		{"29th into February", date(2025, time.January, 29), 1, date(2025, time.February, 28)},
+		{"31st into a 30-day month", date(2025, time.March, 31), 1, date(2025, time.April, 30)},
+		{"across a year", date(2025, time.November, 30), 3, date(2026, time.February, 28)},
+		{"leap day a year on", date(2024, time.February, 29), 12, date(2025, time.February, 28)},
+		{"leap day four years on", date(2024, time.February, 29), 48, date(2028, time.February, 29)},
+		{"zero months", date(2025, time.January, 31), 0, date(2025, time.January, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddMonthsClamped(tt.from, tt.months); !got.Equal(tt.want) {
+				t.Errorf("AddMonthsClamped(%s, %d) = %s, want %s", tt.from.Format(time.DateOnly), tt.months, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestSubscriptionNextRenewal(t *testing.T) {
+	end := date(2025, time.June, 30)
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		from time.Time
+		want *time.Time
+	}{
+		{
+			name: "before start renews on start",
+			sub:  Subscription{StartDate: date(2025, time.March, 10), BillingPeriod: BillingMonth},
+			from: date(2025, time.January, 1),
+			want: ptr(date(2025, time.March, 10)),
+		},
+		{
+			name: "on a renewal day",
+			sub:  Subscription{StartDate: date(2025, time.January, 10), BillingPeriod: BillingMonth},
+			from: date(2025, time.April, 10),
+			want: ptr(date(2025, time.April, 10)),
+		},
+		{
+			name: "31st renews at the end of February",
+			sub:  Subscription{StartDate: date(2025, time.January, 31), BillingPeriod: BillingMonth},
+			from: date(2025, time.February, 1),
+			want: ptr(date(2025, time.February, 28)),
+		},
+		{
+			name: "31st renews on leap day",
+			sub:  Subscription{StartDate: date(2024, time.January, 31), BillingPeriod: BillingMonth},
+			from: date(2024, time.February, 1),
+			want: ptr(date(2024, time.February, 29)),
+		},
+		{
+			name: "31st returns to the 31st after February",
+			sub:  Subscription{StartDate: date(2025, time.January, 31), BillingPeriod: BillingMonth},
+			from: date(2025, time.March, 1),
+			want: ptr(date(2025, time.March, 31)),
+		},
+		{
+			name: "quarterly anchor",
+			sub:  Subscription{StartDate: date(2025, time.January, 31), BillingPeriod: BillingQuarter},
+			from: date(2025, time.February, 15),
+			want: ptr(date(2025, time.April, 30)),
+		},
+		{
+			name: "quarterly anchor on the renewal day",
+			sub:  Subscription{StartDate: date(2025, time.January, 15), BillingPeriod: BillingQuarter},
+			from: date(2025, time.July, 15),
+			want: ptr(date(2025, time.July, 15)),
+		},
+		{
+			name: "every two quarters",
+			sub:  Subscription{StartDate: date(2025, time.January, 15), BillingPeriod: BillingQuarter, BillingInterval: 2},
+			from: date(2025, time.April, 16),
+			want: ptr(date(2025, time.July, 15)),
+		},
+		{
+			name: "yearly anchor",
+			sub:  Subscription{StartDate: date(2023, time.May, 20), BillingPeriod: BillingYear},
+			from: date(2025, time.May, 21),
+			want: ptr(date(2026, time.May, 20)),
+		},
+		{
+			name: "yearly from leap day",
+			sub:  Subscription{StartDate: date(2024, time.February, 29), BillingPeriod: BillingYear},
+			from: date(2024, time.March, 1),
+			want: ptr(date(2025, time.February, 28)),
+		},
+		{
+			name: "yearly from leap day into the next leap year",
+			sub:  Subscription{StartDate: date(2024, time.February, 29), BillingPeriod: BillingYear},
+			from: date(2027, time.March, 1),
+			want: ptr(date(2028, time.February, 29)),
+		},
+		{
+			name: "weekly",
+			sub:  Subscription{StartDate: date(2025, time.January, 1), BillingPeriod: BillingWeek},
+			from: date(2025, time.January, 9),
+			want: ptr(date(2025, time.January, 15)),
+		},
+		{
+			name: "every two weeks",
+			sub:  Subscription{StartDate: date(2025, time.January, 1), BillingPeriod: BillingWeek, BillingInterval: 2},
+			from: date(2025, time.January, 9),
+			want: ptr(date(2025, time.January, 15)),
+		},
+		{
+			name: "time of day is ignored",
+			sub:  Subscription{StartDate: date(2025, time.January, 10), BillingPeriod: BillingMonth},
+			from: time.Date(2025, time.February, 10, 18, 30, 0, 0, time.UTC),
+			want: ptr(date(2025, time.February, 10)),
+		},
+		{
+			name: "renewal on the end date",
+			sub:  Subscription{StartDate: date(2025, time.January, 30), BillingPeriod: BillingMonth, EndDate: &end},
+			from: date(2025, time.June, 1),
+			want: ptr(date(2025, time.June, 30)),
+		},
+		{
+			name: "ended before the next renewal",
+			sub:  Subscription{StartDate: date(2025, time.January, 31), BillingPeriod: BillingQuarter, EndDate: &end},
+			from: date(2025, time.May, 1),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sub.NextRenewal(tt.from)
+
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("NextRenewal(%s) = %v, want %v", tt.from.Format(time.DateOnly), got, tt.want)
+			case !got.Equal(*tt.want):
+				t.Errorf("NextRenewal(%s) = %s, want %s", tt.from.Format(time.DateOnly), got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// Service is a catalog entry subscriptions refer to. Aliases hold the
+// normalised names (including the canonical one) that resolve to it.
+type Service struct {
+	ID           string
+	Name         string
+	Aliases      []string
+	Category     string
+	DefaultPrice *int
+	Website      string
+	CreatedAt    time.Time
+}
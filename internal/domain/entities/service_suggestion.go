@@ -0,0 +1,6 @@
+package entities
+
+type ServiceSuggestion struct {
+	ServiceName   string
+	Subscriptions int
+}
@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+type SubscriptionStatus string
+
+const (
+	StatusTrial     SubscriptionStatus = "trial"
+	StatusActive    SubscriptionStatus = "active"
+	StatusPaused    SubscriptionStatus = "paused"
+	StatusCancelled SubscriptionStatus = "cancelled"
+	StatusExpired   SubscriptionStatus = "expired"
+)
+
+// StatusChange moves a subscription from one stored status to another.
+// Date is the day the change takes effect; EndDate, when set, replaces the
+// subscription end date.
+type StatusChange struct {
+	SubscriptionID string
+	From           SubscriptionStatus
+	To             SubscriptionStatus
+	Date           time.Time
+	EndDate        *time.Time
+}
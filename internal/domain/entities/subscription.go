@@ -3,9 +3,44 @@ package entities
 import "time"
 
 type Subscription struct {
-	ServiceName string
-	Price       int
-	UserID      string
-	StartDate   time.Time
-	EndDate     *time.Time
+	ID              string
+	ServiceID       string
+	ServiceName     string
+	Price           int
+	Currency        Currency
+	BillingPeriod   BillingPeriod
+	BillingInterval int
+	UserID          string
+	StartDate       time.Time
+	EndDate         *time.Time
+	Status          SubscriptionStatus
+	TrialEndDate    *time.Time
+	StatusChangedAt time.Time
+	CancelledAt     *time.Time
+	SplitRule       SplitRule
+
+	// Version is bumped by every change of the subscription.
+	Version int64
+
+	// DeletedAt is set once the subscription is deleted; the row is purged
+	// after the retention period.
+	DeletedAt *time.Time
+
+	// Tags are normalised tag names. On update a nil slice leaves the tags
+	// unchanged and an empty one removes them all.
+	Tags []string
+
+	// PriceEffectiveMonth is the first month an updated Price applies to.
+	PriceEffectiveMonth time.Time
+
+	// Relevance is the service name match score, set only by searches.
+	Relevance float64
+}
+
+// Versions maps subscription IDs to the version a write expects to find them
+// at. A nil Versions skips the check.
+type Versions map[string]int64
+
+func (s Subscription) MonthlyCost() float64 {
+	return s.BillingPeriod.MonthlyCost(s.Price, s.BillingInterval)
 }
@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+// CostFilter selects the subscriptions and the period used by cost calculations.
+// Costs are converted to Currency using the exchange rate in effect for each month.
+type CostFilter struct {
+	UserID      string
+	ServiceName string
+	StartPeriod *time.Time
+	EndPeriod   time.Time
+	Currency    Currency
+}
+
+type SubscriptionCost struct {
+	SubscriptionID  string
+	ServiceName     string
+	UserID          string
+	Price           int
+	Currency        Currency
+	BillingPeriod   BillingPeriod
+	BillingInterval int
+	MonthlyCost     float64
+	Months          int
+	Cost            float64
+}
+
+type MonthlyCost struct {
+	Month       time.Time
+	Total       float64
+	ActiveCount int
+}
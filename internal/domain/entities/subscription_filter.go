@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// SubscriptionFilter selects subscriptions. Zero fields are not filtered on
+// and all date bounds are inclusive. SearchTerms fuzzy-match the service name;
+// a subscription matches when any term does. Tags match subscriptions having
+// all of them. Deleted subscriptions are skipped unless IncludeDeleted is set.
+type SubscriptionFilter struct {
+	UserID         string
+	ServiceNames   []string
+	SearchTerms    []string
+	Tags           []string
+	Currency       Currency
+	BillingPeriod  BillingPeriod
+	PriceMin       *int
+	PriceMax       *int
+	MonthlyCostMin int
+	ActiveOn       *time.Time
+	StartDateFrom  *time.Time
+	StartDateTo    *time.Time
+	EndDateTo      *time.Time
+	IncludeDeleted bool
+}
@@ -0,0 +1,6 @@
+package entities
+
+type Tag struct {
+	Name          string
+	Subscriptions int
+}
@@ -0,0 +1,11 @@
+package errormsgs
+
+import "errors"
+
+// NotApplied reports a valid operation of an all-or-nothing batch left
+// unwritten because another operation failed.
+var NotApplied = errors.New("not applied: another operation of the batch failed")
+
+func IsNotApplied(err error) bool {
+	return errors.Is(err, NotApplied)
+}
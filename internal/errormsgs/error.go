@@ -4,6 +4,12 @@ import "errors"
 
 var NotFound = errors.New("not found :(")
 
+var Conflict = errors.New("conflict")
+
 func IsNotFound(err error) bool {
 	return errors.Is(err, NotFound)
+}
+
+func IsConflict(err error) bool {
+	return errors.Is(err, Conflict)
 }
\ No newline at end of file
@@ -0,0 +1,36 @@
+package errormsgs
+
+import "errors"
+
+var Forbidden = errors.New("forbidden")
+
+// Denial is a Forbidden error carrying a machine-readable reason.
+type Denial struct {
+	Reason string
+}
+
+func (d *Denial) Error() string {
+	return "forbidden: " + d.Reason
+}
+
+func (d *Denial) Is(target error) bool {
+	return target == Forbidden
+}
+
+func Deny(reason string) error {
+	return &Denial{Reason: reason}
+}
+
+func IsForbidden(err error) bool {
+	return errors.Is(err, Forbidden)
+}
+
+// DenialReason returns the reason of a Denial in err, or "" when there is none.
+func DenialReason(err error) string {
+	var denial *Denial
+	if errors.As(err, &denial) {
+		return denial.Reason
+	}
+
+	return ""
+}
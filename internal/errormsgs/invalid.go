@@ -0,0 +1,21 @@
+package errormsgs
+
+import (
+	"errors"
+	"strings"
+)
+
+// invalidDataPrefix starts the message of every error rejecting client input.
+const invalidDataPrefix = "invalid data:"
+
+// IsInvalidData reports whether err, or an error it wraps, rejects the input
+// of the request rather than failing on the server.
+func IsInvalidData(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if strings.HasPrefix(err.Error(), invalidDataPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
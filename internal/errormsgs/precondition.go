@@ -0,0 +1,19 @@
+package errormsgs
+
+import "errors"
+
+// PreconditionFailed reports a write whose If-Match no longer matches the
+// current state of the subscriptions it changes.
+var PreconditionFailed = errors.New("precondition failed: subscription has changed")
+
+func IsPreconditionFailed(err error) bool {
+	return errors.Is(err, PreconditionFailed)
+}
+
+// PreconditionRequired reports a conditional write sent without the ETag of
+// the subscriptions it changes.
+var PreconditionRequired = errors.New("precondition required: send the ETag of the last read as if_match")
+
+func IsPreconditionRequired(err error) bool {
+	return errors.Is(err, PreconditionRequired)
+}
@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// APIKeysRepo reads api_keys outside tenant transactions: a key is what
+// tells which organisation a request belongs to.
+type APIKeysRepo struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewAPIKeysRepo(db *sql.DB) *APIKeysRepo {
+	return &APIKeysRepo{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func (ar *APIKeysRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (entities.APIKey, error) {
+	logger.Log.Info("Repo: GetAPIKeyByHash called")
+
+	query, args, err := ar.builder.
+		Select("id", "organization_id", "name", "role", "COALESCE(user_id::text, '')", "to_json(scopes)", "expires_at", "revoked_at").
+		From("api_keys").
+		Where("key_hash = ?", keyHash).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return entities.APIKey{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var key entities.APIKey
+	var scopes textArray
+
+	err = ar.db.QueryRowContext(ctx, query, args...).
+		Scan(&key.ID, &key.OrganizationID, &key.Name, &key.Role, &key.UserID, &scopes, &key.ExpiresAt, &key.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error("Repo: API key not found")
+
+			return entities.APIKey{}, errormsgs.NotFound
+		}
+
+		logger.Log.Error("Repo: Failed to scan API key", "error", err)
+
+		return entities.APIKey{}, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	key.Scopes = scopes
+
+	return key, nil
+}
+
+// TouchAPIKey records that the key has just been used.
+func (ar *APIKeysRepo) TouchAPIKey(ctx context.Context, id string) error {
+	query, args, err := ar.builder.
+		Update("api_keys").
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build update query", "error", err)
+
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := ar.db.ExecContext(ctx, query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to touch API key", "error", err)
+
+		return fmt.Errorf("failed to touch API key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/internal/requestid"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// subscriptionSnapshotSQL renders the Subscriptions row s with its tags as the
+// JSON kept in the audit log.
+const subscriptionSnapshotSQL = "(to_jsonb(s) - 'organization_id') || jsonb_build_object('tags', " +
+	"ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id " +
+	"WHERE st.subscription_id = s.id ORDER BY t.name))"
+
+// subscriptionSnapshot is the state of a subscription at one point of a transaction.
+type subscriptionSnapshot struct {
+	id      string
+	userID  string
+	version int64
+	data    []byte
+}
+
+func snapshotIDs(snapshots []subscriptionSnapshot) []string {
+	ids := make([]string, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		ids = append(ids, snapshot.id)
+	}
+
+	return ids
+}
+
+// checkVersions fails with PreconditionFailed unless the snapshots are exactly
+// the subscriptions expected, at the expected versions. A nil expected passes.
+func checkVersions(snapshots []subscriptionSnapshot, expected entities.Versions) error {
+	if expected == nil {
+		return nil
+	}
+
+	if len(snapshots) != len(expected) {
+		logger.Log.Error("Repo: Subscriptions changed since read", "expected", len(expected), "found", len(snapshots))
+
+		return errormsgs.PreconditionFailed
+	}
+
+	for _, snapshot := range snapshots {
+		if version, ok := expected[snapshot.id]; !ok || version != snapshot.version {
+			logger.Log.Error("Repo: Subscription version mismatch", "id", snapshot.id, "version", snapshot.version)
+
+			return errormsgs.PreconditionFailed
+		}
+	}
+
+	return nil
+}
+
+// auditActor names who acts in ctx, e.g. "jwt:<subject>", or "system" outside
+// an authenticated request.
+func auditActor(ctx context.Context) string {
+	principal, ok := auth.PrincipalFrom(ctx)
+	if !ok {
+		return "system"
+	}
+
+	return principal.Method + ":" + principal.Subject
+}
+
+// snapshotSubscriptions locks the subscriptions matching where and returns
+// their snapshots ordered by ID.
+func (sr *SubsRepo) snapshotSubscriptions(tx *sql.Tx, where squirrel.Sqlizer) ([]subscriptionSnapshot, error) {
+	query, args, err := sr.builder.
+		Select("s.id", "s.user_id", "s.version", subscriptionSnapshotSQL).
+		From("Subscriptions s").
+		Where(where).
+		OrderBy("s.id").
+		Suffix("FOR UPDATE OF s").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build snapshot query", "error", err)
+
+		return nil, fmt.Errorf("failed to build snapshot query: %w", err)
+	}
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to snapshot subscriptions", "error", err)
+
+		return nil, fmt.Errorf("failed to snapshot subscriptions: %w", err)
+	}
+
+	defer rows.Close()
+
+	snapshots := make([]subscriptionSnapshot, 0)
+
+	for rows.Next() {
+		var snapshot subscriptionSnapshot
+		if err := rows.Scan(&snapshot.id, &snapshot.userID, &snapshot.version, &snapshot.data); err != nil {
+			logger.Log.Error("Repo: Failed to scan snapshot", "error", err)
+
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// auditSubscriptions records action for every subscription in before or
+// after, pairing the two snapshots of the same subscription.
+func (sr *SubsRepo) auditSubscriptions(ctx context.Context, tx *sql.Tx, action entities.AuditAction, before, after []subscriptionSnapshot) error {
+	if len(before) == 0 && len(after) == 0 {
+		return nil
+	}
+
+	type entry struct {
+		userID        string
+		before, after any
+	}
+
+	entries := make(map[string]*entry, max(len(before), len(after)))
+	order := make([]string, 0, len(entries))
+
+	for _, snapshot := range before {
+		entries[snapshot.id] = &entry{userID: snapshot.userID, before: snapshot.data}
+		order = append(order, snapshot.id)
+	}
+
+	for _, snapshot := range after {
+		if e, ok := entries[snapshot.id]; ok {
+			e.after = snapshot.data
+
+			continue
+		}
+
+		entries[snapshot.id] = &entry{userID: snapshot.userID, after: snapshot.data}
+		order = append(order, snapshot.id)
+	}
+
+	var requestID any
+	if id := requestid.RequestID(ctx); id != "" {
+		requestID = id
+	}
+
+	actor := auditActor(ctx)
+
+	builder := sr.builder.
+		Insert("subscription_audit").
+		Columns("subscription_id", "user_id", "actor", "action", "before", "after", "request_id")
+
+	for _, id := range order {
+		e := entries[id]
+		builder = builder.Values(id, e.userID, actor, action, squirrel.Expr("?::jsonb", e.before), squirrel.Expr("?::jsonb", e.after), requestID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build audit query", "error", err)
+
+		return fmt.Errorf("failed to build audit query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to write audit log", "error", err)
+
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscriptionAudit returns the audit log of a subscription, oldest first.
+func (sr *SubsRepo) GetSubscriptionAudit(ctx context.Context, subscriptionID string) ([]entities.AuditEntry, error) {
+	logger.Log.Info("Repo: GetSubscriptionAudit called", "id", subscriptionID)
+
+	query, args, err := sr.builder.
+		Select("id", "subscription_id", "user_id", "actor", "action", "before", "after", "COALESCE(request_id, '')", "created_at").
+		From("subscription_audit").
+		Where("subscription_id = ?", subscriptionID).
+		OrderBy("id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build audit query", "error", err)
+
+		return nil, fmt.Errorf("failed to build audit query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute audit query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute audit query: %w", err)
+	}
+
+	defer rows.Close()
+
+	entries := make([]entities.AuditEntry, 0)
+
+	for rows.Next() {
+		var entry entities.AuditEntry
+		var before, after []byte
+
+		if err := rows.Scan(&entry.ID, &entry.SubscriptionID, &entry.UserID, &entry.Actor, &entry.Action,
+			&before, &after, &entry.RequestID, &entry.CreatedAt); err != nil {
+			logger.Log.Error("Repo: Failed to scan audit row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+
+		entry.Before = before
+		entry.After = after
+
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Audit log fetched successfully", "id", subscriptionID, "count", len(entries))
+
+	return entries, nil
+}
@@ -0,0 +1,136 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// ApplySubscriptionBatch writes the whole batch, including its new catalog
+// services, in one transaction and returns the IDs of the created
+// subscriptions in order. Creations use multi-row inserts.
+func (sr *SubsRepo) ApplySubscriptionBatch(ctx context.Context, batch entities.SubscriptionBatch) ([]string, error) {
+	logger.Log.Info("Repo: ApplySubscriptionBatch called", "create", len(batch.Create), "replace", len(batch.Replace), "delete", len(batch.Delete))
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		logger.Log.Error("Failed to start transaction", "error", err)
+
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	var create []entities.Subscription
+
+	create, err = sr.addCatalogServices(tx, batch.Services, batch.Create)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+
+	ids, err = sr.createSubscriptions(ctx, tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(batch.Replace) > 0 {
+		replaced := make([]string, 0, len(batch.Replace))
+
+		for _, subscription := range batch.Replace {
+			replaced = append(replaced, subscription.ID)
+		}
+
+		if err = sr.replaceSubscriptions(ctx, tx, batch.Replace, batch.Expected.Only(replaced)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(batch.Delete) > 0 {
+		var rowsAffected int64
+
+		rowsAffected, err = sr.softDeleteSubscriptions(ctx, tx, squirrel.Eq{"id": batch.Delete, "deleted_at": nil}, batch.Expected.Only(batch.Delete))
+		if err != nil {
+			return nil, err
+		}
+
+		if rowsAffected != int64(len(batch.Delete)) {
+			logger.Log.Error("Repo: Subscriptions not found for delete", "expected", len(batch.Delete), "found", rowsAffected)
+
+			err = errormsgs.NotFound
+
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return nil, err
+	}
+
+	logger.Log.Info("Repo: Subscription batch applied successfully", "create", len(batch.Create), "replace", len(batch.Replace), "delete", len(batch.Delete))
+
+	return ids, nil
+}
+
+// addCatalogServices adds services to the catalog, keeping any a concurrent
+// request added under the same name, and returns subs with the IDs of the
+// services they name filled in.
+func (sr *SubsRepo) addCatalogServices(tx *sql.Tx, services []entities.Service, subs []entities.Subscription) ([]entities.Subscription, error) {
+	if len(services) == 0 {
+		return subs, nil
+	}
+
+	ids := make(map[string]string, len(services))
+
+	for _, service := range services {
+		query, args, err := sr.builder.
+			Insert("services").
+			Columns("name", "aliases").
+			Values(service.Name, service.Aliases).
+			Suffix("ON CONFLICT (organization_id, lower(name)) DO UPDATE SET name = services.name RETURNING id").
+			ToSql()
+
+		if err != nil {
+			logger.Log.Error("Repo: Failed to build insert query", "error", err)
+
+			return nil, fmt.Errorf("failed to build query: %w", err)
+		}
+
+		var id string
+
+		if err := tx.QueryRow(query, args...).Scan(&id); err != nil {
+			logger.Log.Error("Repo: Failed to execute insert", "error", err)
+
+			return nil, fmt.Errorf("failed to create service: %w", constraintError(err))
+		}
+
+		ids[service.Name] = id
+	}
+
+	resolved := slices.Clone(subs)
+
+	for i := range resolved {
+		if resolved[i].ServiceID == "" {
+			resolved[i].ServiceID = ids[resolved[i].ServiceName]
+		}
+	}
+
+	logger.Log.Info("Repo: Services added to catalog", "count", len(services))
+
+	return resolved, nil
+}
@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+const exchangeRatesBatchSize = 1000
+
+type ExchangeRatesRepo struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewExchangeRatesRepo(db *sql.DB) *ExchangeRatesRepo {
+	return &ExchangeRatesRepo{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// UpsertExchangeRates saves rates for the organisation of ctx.
+func (er *ExchangeRatesRepo) UpsertExchangeRates(ctx context.Context, rates []entities.ExchangeRate) error {
+	logger.Log.Info("Repo: UpsertExchangeRates called", "count", len(rates))
+
+	if len(rates) == 0 {
+		return nil
+	}
+
+	tx, err := beginTenant(ctx, er.db)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	for start := 0; start < len(rates); start += exchangeRatesBatchSize {
+		end := min(start+exchangeRatesBatchSize, len(rates))
+
+		builder := er.builder.
+			Insert("exchange_rates").
+			Columns("rate_date", "from_currency", "to_currency", "rate").
+			Suffix("ON CONFLICT (organization_id, from_currency, to_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate")
+
+		for _, rate := range rates[start:end] {
+			builder = builder.Values(rate.Date, rate.From, rate.To, rate.Rate)
+		}
+
+		var query string
+		var args []any
+
+		query, args, err = builder.ToSql()
+		if err != nil {
+			logger.Log.Error("Repo: Failed to build upsert query", "error", err)
+
+			return fmt.Errorf("failed to build query: %w", err)
+		}
+
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to execute upsert", "error", err)
+
+			return fmt.Errorf("failed to save exchange rates: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Repo: Exchange rates saved successfully", "count", len(rates))
+
+	return nil
+}
+
+// GetExchangeRates returns the rates of the organisation of ctx.
+func (er *ExchangeRatesRepo) GetExchangeRates(ctx context.Context, from, to entities.Currency) ([]entities.ExchangeRate, error) {
+	logger.Log.Info("Repo: GetExchangeRates called", "from", from, "to", to)
+
+	builder := er.builder.
+		Select("rate_date", "from_currency", "to_currency", "rate::float8").
+		From("exchange_rates").
+		OrderBy("from_currency", "to_currency", "rate_date")
+
+	if from != "" {
+		builder = builder.Where("from_currency = ?", from)
+	}
+
+	if to != "" {
+		builder = builder.Where("to_currency = ?", to)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, er.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute select query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	defer rows.Close()
+
+	rates := make([]entities.ExchangeRate, 0)
+
+	for rows.Next() {
+		var rate entities.ExchangeRate
+		if err := rows.Scan(&rate.Date, &rate.From, &rate.To, &rate.Rate); err != nil {
+			logger.Log.Error("Repo: Failed to scan row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Exchange rates fetched successfully", "count", len(rates))
+
+	return rates, nil
+}
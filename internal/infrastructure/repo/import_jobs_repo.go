@@ -0,0 +1,244 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/internal/tenant"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+var importJobColumns = []string{
+	"id", "created_by", "status", "dry_run", "total_rows", "valid_rows", "invalid_rows", "imported_rows",
+	"row_errors", "COALESCE(error, '')", "created_at", "finished_at",
+}
+
+// importRowError is the JSON form of a row error kept in import_jobs.row_errors.
+type importRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type ImportJobsRepo struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewImportJobsRepo(db *sql.DB) *ImportJobsRepo {
+	return &ImportJobsRepo{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func scanImportJob(row rowScanner) (entities.ImportJob, error) {
+	var job entities.ImportJob
+	var rowErrors []byte
+
+	err := row.Scan(&job.ID, &job.CreatedBy, &job.Status, &job.DryRun, &job.TotalRows, &job.ValidRows, &job.InvalidRows, &job.ImportedRows,
+		&rowErrors, &job.Error, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		return job, err
+	}
+
+	var decoded []importRowError
+	if err := json.Unmarshal(rowErrors, &decoded); err != nil {
+		return job, fmt.Errorf("failed to decode row errors: %w", err)
+	}
+
+	job.RowErrors = make([]entities.ImportRowError, 0, len(decoded))
+
+	for _, rowError := range decoded {
+		job.RowErrors = append(job.RowErrors, entities.ImportRowError{Line: rowError.Line, Error: rowError.Error})
+	}
+
+	return job, nil
+}
+
+// CreateImportJob records a new pending job and returns it as stored.
+func (ir *ImportJobsRepo) CreateImportJob(ctx context.Context, job entities.ImportJob) (entities.ImportJob, error) {
+	logger.Log.Info("Repo: CreateImportJob called", "created_by", job.CreatedBy, "total_rows", job.TotalRows)
+
+	query, args, err := ir.builder.
+		Insert("import_jobs").
+		Columns("created_by", "status", "dry_run", "total_rows").
+		Values(job.CreatedBy, entities.ImportPending, job.DryRun, job.TotalRows).
+		Suffix("RETURNING " + strings.Join(importJobColumns, ", ")).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build insert query", "error", err)
+
+		return entities.ImportJob{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, ir.db)
+	if err != nil {
+		return entities.ImportJob{}, err
+	}
+
+	defer rollback(tx)
+
+	created, err := scanImportJob(tx.QueryRow(query, args...))
+	if err != nil {
+		logger.Log.Error("Repo: Failed to create import job", "error", err)
+
+		return entities.ImportJob{}, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return entities.ImportJob{}, err
+	}
+
+	logger.Log.Info("Repo: Import job created successfully", "id", created.ID)
+
+	return created, nil
+}
+
+func (ir *ImportJobsRepo) GetImportJob(ctx context.Context, id string) (entities.ImportJob, error) {
+	logger.Log.Info("Repo: GetImportJob called", "id", id)
+
+	query, args, err := ir.builder.
+		Select(importJobColumns...).
+		From("import_jobs").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return entities.ImportJob{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, ir.db)
+	if err != nil {
+		return entities.ImportJob{}, err
+	}
+
+	defer rollback(tx)
+
+	job, err := scanImportJob(tx.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error("Repo: Import job not found", "id", id)
+
+			return entities.ImportJob{}, errormsgs.NotFound
+		}
+
+		logger.Log.Error("Repo: Failed to scan import job", "error", err)
+
+		return entities.ImportJob{}, fmt.Errorf("failed to scan import job: %w", err)
+	}
+
+	return job, nil
+}
+
+// UpdateImportJob saves the progress of a job. Finished jobs get finished_at.
+func (ir *ImportJobsRepo) UpdateImportJob(ctx context.Context, job entities.ImportJob) error {
+	logger.Log.Info("Repo: UpdateImportJob called", "id", job.ID, "status", job.Status)
+
+	rowErrors := make([]importRowError, 0, len(job.RowErrors))
+
+	for _, rowError := range job.RowErrors {
+		rowErrors = append(rowErrors, importRowError{Line: rowError.Line, Error: rowError.Error})
+	}
+
+	encoded, err := json.Marshal(rowErrors)
+	if err != nil {
+		return fmt.Errorf("failed to encode row errors: %w", err)
+	}
+
+	builder := ir.builder.
+		Update("import_jobs").
+		Set("status", job.Status).
+		Set("valid_rows", job.ValidRows).
+		Set("invalid_rows", job.InvalidRows).
+		Set("imported_rows", job.ImportedRows).
+		Set("row_errors", squirrel.Expr("?::jsonb", encoded)).
+		Set("error", nullableString(job.Error)).
+		Where(squirrel.Eq{"id": job.ID})
+
+	if job.Status == entities.ImportCompleted || job.Status == entities.ImportFailed {
+		builder = builder.Set("finished_at", squirrel.Expr("now()"))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build update query", "error", err)
+
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	result, err := execTenant(ctx, ir.db, query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to update import job", "error", err)
+
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		logger.Log.Error("Repo: Import job not found", "id", job.ID)
+
+		return errormsgs.NotFound
+	}
+
+	return nil
+}
+
+// FailUnfinishedImportJobs marks the pending and running jobs of every
+// organisation as failed with reason and returns how many there were.
+func (ir *ImportJobsRepo) FailUnfinishedImportJobs(ctx context.Context, reason string) (int64, error) {
+	logger.Log.Info("Repo: FailUnfinishedImportJobs called")
+
+	query, args, err := ir.builder.
+		Update("import_jobs").
+		Set("status", entities.ImportFailed).
+		Set("error", reason).
+		Set("finished_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"status": []entities.ImportStatus{entities.ImportPending, entities.ImportRunning}}).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build update query", "error", err)
+
+		return 0, fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	organizationIDs, err := organizationIDs(ctx, ir.db, ir.builder)
+	if err != nil {
+		return 0, err
+	}
+
+	var failed int64
+
+	for _, organizationID := range organizationIDs {
+		result, err := execTenant(tenant.WithOrganization(ctx, organizationID), ir.db, query, args...)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to fail import jobs", "organization_id", organizationID, "error", err)
+
+			return failed, fmt.Errorf("failed to fail import jobs: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+			return failed, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+
+		failed += rowsAffected
+	}
+
+	logger.Log.Info("Repo: Unfinished import jobs failed", "rows_affected", failed)
+
+	return failed, nil
+}
@@ -0,0 +1,165 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// activeMemberSQL matches the members of s sharing month m, i.e. members on
+// its last day.
+const activeMemberSQL = "mb.subscription_id = s.id " +
+	"AND mb.joined_on <= (m.month + interval '1 month - 1 day')::date " +
+	"AND (mb.left_on IS NULL OR mb.left_on > (m.month + interval '1 month - 1 day')::date)"
+
+// participantsSQL expands a subscription month into one row per paying user
+// as sh.user_id with the fraction of the cost they bear as sh.fraction. The
+// owner pays whatever the active members do not. It needs the price joined
+// by priceJoin.
+const participantsSQL = "LATERAL (" +
+	"SELECT s.user_id AS user_id, CASE s.split_rule " +
+	"WHEN 'percent' THEN GREATEST(100 - COALESCE(SUM(mb.share), 0), 0) / 100 " +
+	"WHEN 'fixed' THEN COALESCE(GREATEST(COALESCE(pc.price, s.price) - COALESCE(SUM(mb.share), 0), 0) / NULLIF(COALESCE(pc.price, s.price), 0), 1) " +
+	"ELSE 1.0 / (COUNT(mb.user_id) + 1) END AS fraction " +
+	"FROM subscription_members mb WHERE " + activeMemberSQL + " " +
+	"UNION ALL " +
+	"SELECT mb.user_id, CASE s.split_rule " +
+	"WHEN 'percent' THEN mb.share / 100 " +
+	"WHEN 'fixed' THEN COALESCE(LEAST(mb.share / NULLIF(COALESCE(pc.price, s.price), 0), 1), 0) " +
+	"ELSE 1.0 / (COUNT(*) OVER () + 1) END " +
+	"FROM subscription_members mb WHERE " + activeMemberSQL +
+	") sh"
+
+// participantMatch narrows subscriptions to those the user owns or has been a member of.
+const participantMatch = "(s.user_id = ? OR s.id IN (SELECT pm.subscription_id FROM subscription_members pm WHERE pm.user_id = ?))"
+
+var memberColumns = []string{"subscription_id", "user_id", "share::float8", "joined_on", "left_on"}
+
+func (sr *SubsRepo) GetSubscriptionMembers(ctx context.Context, subscriptionID string) ([]entities.SubscriptionMember, error) {
+	logger.Log.Info("Repo: GetSubscriptionMembers called", "id", subscriptionID)
+
+	query, args, err := sr.builder.
+		Select(memberColumns...).
+		From("subscription_members").
+		Where("subscription_id = ?", subscriptionID).
+		OrderBy("joined_on", "user_id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build members query", "error", err)
+
+		return nil, fmt.Errorf("failed to build members query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute members query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute members query: %w", err)
+	}
+
+	defer rows.Close()
+
+	members := make([]entities.SubscriptionMember, 0)
+
+	for rows.Next() {
+		var member entities.SubscriptionMember
+		if err := rows.Scan(&member.SubscriptionID, &member.UserID, &member.Share, &member.JoinedOn, &member.LeftOn); err != nil {
+			logger.Log.Error("Repo: Failed to scan member row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan member row: %w", err)
+		}
+
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Members fetched successfully", "id", subscriptionID, "count", len(members))
+
+	return members, nil
+}
+
+// AddSubscriptionMember adds a membership. A user with an open membership of
+// the subscription is a Conflict.
+func (sr *SubsRepo) AddSubscriptionMember(ctx context.Context, member entities.SubscriptionMember) error {
+	logger.Log.Info("Repo: AddSubscriptionMember called", "id", member.SubscriptionID, "user_id", member.UserID)
+
+	query, args, err := sr.builder.
+		Insert("subscription_members").
+		Columns("subscription_id", "user_id", "share", "joined_on").
+		Values(member.SubscriptionID, member.UserID, member.Share, member.JoinedOn).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build member insert query", "error", err)
+
+		return fmt.Errorf("failed to build member insert query: %w", err)
+	}
+
+	if _, err := execTenant(ctx, sr.db, query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to add member", "error", err)
+
+		return constraintError(fmt.Errorf("failed to add member: %w", err))
+	}
+
+	logger.Log.Info("Repo: Member added successfully", "id", member.SubscriptionID, "user_id", member.UserID)
+
+	return nil
+}
+
+// RemoveSubscriptionMember ends the open membership of the user on leftOn.
+func (sr *SubsRepo) RemoveSubscriptionMember(ctx context.Context, subscriptionID, userID string, leftOn time.Time) error {
+	logger.Log.Info("Repo: RemoveSubscriptionMember called", "id", subscriptionID, "user_id", userID, "left_on", leftOn)
+
+	query, args, err := sr.builder.
+		Update("subscription_members").
+		Set("left_on", leftOn).
+		Where("subscription_id = ? AND user_id = ? AND left_on IS NULL AND joined_on <= ?", subscriptionID, userID, leftOn).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build member update query", "error", err)
+
+		return fmt.Errorf("failed to build member update query: %w", err)
+	}
+
+	result, err := execTenant(ctx, sr.db, query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to remove member", "error", err)
+
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		logger.Log.Error("Repo: No open membership found", "id", subscriptionID, "user_id", userID)
+
+		return errormsgs.NotFound
+	}
+
+	logger.Log.Info("Repo: Member removed successfully", "id", subscriptionID, "user_id", userID)
+
+	return nil
+}
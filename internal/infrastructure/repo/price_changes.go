@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// currentPriceSQL is the price in effect for the current month. It keeps
+// Subscriptions.price in sync with the price history on update.
+const currentPriceSQL = "COALESCE((SELECT pc.price FROM subscription_price_changes pc " +
+	"WHERE pc.subscription_id = Subscriptions.id AND pc.effective_month <= date_trunc('month', now()) " +
+	"ORDER BY pc.effective_month DESC LIMIT 1), price)"
+
+// priceJoin picks the price in effect for each month as pc.price. Months
+// before the first recorded change use the earliest recorded price.
+const priceJoin = "LEFT JOIN LATERAL (SELECT spc.price FROM subscription_price_changes spc " +
+	"WHERE spc.subscription_id = s.id " +
+	"ORDER BY CASE WHEN spc.effective_month <= m.month THEN spc.effective_month END DESC NULLS LAST, spc.effective_month " +
+	"LIMIT 1) pc ON true"
+
+// appendPriceChange records price from the month of effectiveMonth for every
+// subscription matching where, replacing a change already recorded for that month.
+func (sr *SubsRepo) appendPriceChange(tx *sql.Tx, price int, effectiveMonth time.Time, where squirrel.Eq) error {
+	selectQuery := sr.builder.
+		Select("id").
+		Column(squirrel.Expr("date_trunc('month', ?::date)", effectiveMonth)).
+		Column(squirrel.Expr("?::integer", price)).
+		From("Subscriptions").
+		Where(where)
+
+	query, args, err := sr.builder.
+		Insert("subscription_price_changes").
+		Columns("subscription_id", "effective_month", "price").
+		Select(selectQuery).
+		Suffix("ON CONFLICT (subscription_id, effective_month) DO UPDATE SET price = EXCLUDED.price, created_at = now()").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build price change query", "error", err)
+
+		return fmt.Errorf("failed to build price change query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to record price change", "error", err)
+
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+
+	return nil
+}
+
+func (sr *SubsRepo) GetPriceChanges(ctx context.Context, subscriptionID string) ([]entities.PriceChange, error) {
+	logger.Log.Info("Repo: GetPriceChanges called", "id", subscriptionID)
+
+	query, args, err := sr.builder.
+		Select("subscription_id", "effective_month", "price", "created_at").
+		From("subscription_price_changes").
+		Where("subscription_id = ?", subscriptionID).
+		OrderBy("effective_month").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build price history query", "error", err)
+
+		return nil, fmt.Errorf("failed to build price history query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute price history query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute price history query: %w", err)
+	}
+
+	defer rows.Close()
+
+	changes := make([]entities.PriceChange, 0)
+
+	for rows.Next() {
+		var change entities.PriceChange
+		if err := rows.Scan(&change.SubscriptionID, &change.EffectiveMonth, &change.Price, &change.CreatedAt); err != nil {
+			logger.Log.Error("Repo: Failed to scan price change row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan price change row: %w", err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Price history fetched successfully", "id", subscriptionID, "count", len(changes))
+
+	return changes, nil
+}
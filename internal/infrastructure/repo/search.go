@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// searchMatch matches service names similar to any of the terms, either as a
+// whole (%) or as a word inside a longer name (<%). Both operators use the
+// pg_trgm index on lower(service_name).
+func searchMatch(terms []string) squirrel.Or {
+	match := make(squirrel.Or, 0, len(terms))
+
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		match = append(match, squirrel.Expr("(lower(service_name) % ? OR ? <% lower(service_name))", term, term))
+	}
+
+	return match
+}
+
+// searchRankSQL scores a row by its best similarity to any of the terms.
+func searchRankSQL(terms []string) (string, []any) {
+	if len(terms) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, 0, len(terms))
+	args := make([]any, 0, len(terms)*2)
+
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		parts = append(parts, "similarity(lower(service_name), ?), word_similarity(?, lower(service_name))")
+		args = append(args, term, term)
+	}
+
+	return "GREATEST(" + strings.Join(parts, ", ") + ")::float8", args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestServiceNames returns distinct service names starting with any of the
+// prefixes, most subscribed first.
+func (sr *SubsRepo) SuggestServiceNames(ctx context.Context, prefixes []string, limit uint64) ([]entities.ServiceSuggestion, error) {
+	logger.Log.Info("Repo: SuggestServiceNames called", "prefixes", prefixes, "limit", limit)
+
+	match := make(squirrel.Or, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		match = append(match, squirrel.Expr("lower(service_name) LIKE ?", likeEscaper.Replace(strings.ToLower(prefix))+"%"))
+	}
+
+	query, args, err := sr.builder.
+		Select("service_name", "COUNT(*)").
+		From("Subscriptions").
+		Where(match).
+		Where(notDeleted).
+		GroupBy("service_name").
+		OrderBy("COUNT(*) DESC", "service_name").
+		Limit(limit).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build suggest query", "error", err)
+
+		return nil, fmt.Errorf("failed to build suggest query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute suggest query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute suggest query: %w", err)
+	}
+
+	defer rows.Close()
+
+	suggestions := make([]entities.ServiceSuggestion, 0)
+
+	for rows.Next() {
+		var suggestion entities.ServiceSuggestion
+		if err := rows.Scan(&suggestion.ServiceName, &suggestion.Subscriptions); err != nil {
+			logger.Log.Error("Repo: Failed to scan suggestion row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan suggestion row: %w", err)
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Service names suggested successfully", "count", len(suggestions))
+
+	return suggestions, nil
+}
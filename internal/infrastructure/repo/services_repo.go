@@ -0,0 +1,501 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var serviceColumns = []string{"id", "name", "to_json(aliases)", "category", "default_price", "website", "created_at"}
+
+// textArray scans a text[] column selected with to_json.
+type textArray []string
+
+func (a *textArray) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case nil:
+		*a = nil
+
+		return nil
+	}
+
+	return fmt.Errorf("cannot scan %T into text array", src)
+}
+
+// serviceNameMatch matches subscriptions whose service name equals one of the
+// names or whose catalog entry has one of them as an alias.
+func serviceNameMatch(alias string, names []string) squirrel.Sqlizer {
+	if alias != "" {
+		alias += "."
+	}
+
+	return squirrel.Expr(
+		"("+alias+"service_name = ANY(?) OR "+alias+"service_id IN (SELECT sv.id FROM services sv "+
+			"WHERE sv.aliases && ARRAY(SELECT regexp_replace(lower(btrim(n)), '\\s+', ' ', 'g') FROM unnest(?::text[]) n)))",
+		names, names,
+	)
+}
+
+// constraintError reports unique and foreign key violations as errormsgs.Conflict.
+func constraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == "23505" || pgErr.Code == "23503") {
+		return fmt.Errorf("%w: %s", errormsgs.Conflict, pgErr.Message)
+	}
+
+	return err
+}
+
+type ServicesRepo struct {
+	db      *sql.DB
+	builder squirrel.StatementBuilderType
+}
+
+func NewServicesRepo(db *sql.DB) *ServicesRepo {
+	return &ServicesRepo{
+		db:      db,
+		builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+func scanService(row rowScanner) (entities.Service, error) {
+	var service entities.Service
+	var aliases textArray
+	var category, website sql.NullString
+
+	err := row.Scan(&service.ID, &service.Name, &aliases, &category, &service.DefaultPrice, &website, &service.CreatedAt)
+
+	service.Aliases = aliases
+	service.Category = category.String
+	service.Website = website.String
+
+	return service, err
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func (sr *ServicesRepo) CreateService(ctx context.Context, service entities.Service) (string, error) {
+	logger.Log.Info("Repo: CreateService called", "name", service.Name)
+
+	query, args, err := sr.builder.
+		Insert("services").
+		Columns("name", "aliases", "category", "default_price", "website").
+		Values(service.Name, service.Aliases, nullableString(service.Category), service.DefaultPrice, nullableString(service.Website)).
+		Suffix("RETURNING id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build insert query", "error", err)
+
+		return "", fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return "", err
+	}
+
+	defer rollback(tx)
+
+	var id string
+
+	if err := tx.QueryRow(query, args...).Scan(&id); err != nil {
+		logger.Log.Error("Repo: Failed to execute insert", "error", err)
+
+		return "", fmt.Errorf("failed to create service: %w", constraintError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return "", err
+	}
+
+	logger.Log.Info("Repo: Service created successfully", "id", id, "name", service.Name)
+
+	return id, nil
+}
+
+func (sr *ServicesRepo) GetServiceByID(ctx context.Context, id string) (entities.Service, error) {
+	logger.Log.Info("Repo: GetServiceByID called", "id", id)
+
+	query, args, err := sr.builder.
+		Select(serviceColumns...).
+		From("services").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return entities.Service{}, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return entities.Service{}, err
+	}
+
+	defer rollback(tx)
+
+	service, err := scanService(tx.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error("Repo: Service not found", "id", id)
+
+			return entities.Service{}, errormsgs.NotFound
+		}
+
+		logger.Log.Error("Repo: Failed to scan service", "error", err)
+
+		return entities.Service{}, fmt.Errorf("failed to scan service: %w", err)
+	}
+
+	return service, nil
+}
+
+func (sr *ServicesRepo) GetServices(ctx context.Context, category string) ([]entities.Service, error) {
+	logger.Log.Info("Repo: GetServices called", "category", category)
+
+	builder := sr.builder.
+		Select(serviceColumns...).
+		From("services").
+		OrderBy("name")
+
+	if category != "" {
+		builder = builder.Where("category = ?", category)
+	}
+
+	return sr.queryServices(ctx, builder)
+}
+
+// FindServicesByAliases returns the services owning any of the normalised aliases.
+func (sr *ServicesRepo) FindServicesByAliases(ctx context.Context, aliases []string) ([]entities.Service, error) {
+	logger.Log.Info("Repo: FindServicesByAliases called", "aliases", aliases)
+
+	builder := sr.builder.
+		Select(serviceColumns...).
+		From("services").
+		Where("aliases && ?", aliases).
+		OrderBy("name")
+
+	return sr.queryServices(ctx, builder)
+}
+
+func (sr *ServicesRepo) queryServices(ctx context.Context, builder squirrel.SelectBuilder) ([]entities.Service, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute select query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	defer rows.Close()
+
+	services := make([]entities.Service, 0)
+
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to scan row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		services = append(services, service)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	return services, nil
+}
+
+// UpdateService replaces the catalog entry and renames its subscriptions to
+// the new canonical name.
+func (sr *ServicesRepo) UpdateService(ctx context.Context, service entities.Service) error {
+	logger.Log.Info("Repo: UpdateService called", "id", service.ID)
+
+	query, args, err := sr.builder.
+		Update("services").
+		Set("name", service.Name).
+		Set("aliases", service.Aliases).
+		Set("category", nullableString(service.Category)).
+		Set("default_price", service.DefaultPrice).
+		Set("website", nullableString(service.Website)).
+		Where("id = ?", service.ID).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build update query", "error", err)
+
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		logger.Log.Error("Failed to start transaction", "error", err)
+
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute update", "error", err)
+
+		return fmt.Errorf("failed to update service: %w", constraintError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		logger.Log.Error("Repo: No service found for update", "id", service.ID)
+
+		err = errormsgs.NotFound
+
+		return err
+	}
+
+	query, args, err = sr.builder.
+		Update("Subscriptions").
+		Set("service_name", service.Name).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("service_id = ?", service.ID).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build rename query", "error", err)
+
+		return fmt.Errorf("failed to build rename query: %w", err)
+	}
+
+	if _, err = tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to rename subscriptions", "error", err)
+
+		return fmt.Errorf("failed to rename subscriptions: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Repo: Service updated successfully", "id", service.ID)
+
+	return nil
+}
+
+// DeleteService fails with errormsgs.Conflict while subscriptions refer to the service.
+func (sr *ServicesRepo) DeleteService(ctx context.Context, id string) error {
+	logger.Log.Info("Repo: DeleteService called", "id", id)
+
+	query, args, err := sr.builder.
+		Delete("services").
+		Where("id = ?", id).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build delete query", "error", err)
+
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	result, err := execTenant(ctx, sr.db, query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute delete", "error", err)
+
+		return fmt.Errorf("failed to delete service: %w", constraintError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		logger.Log.Error("Repo: No service found for delete", "id", id)
+
+		return errormsgs.NotFound
+	}
+
+	logger.Log.Info("Repo: Service deleted successfully", "id", id)
+
+	return nil
+}
+
+// MergeServices moves the subscriptions and aliases of the source service to
+// the target and deletes the source.
+func (sr *ServicesRepo) MergeServices(ctx context.Context, targetID, sourceID string) error {
+	logger.Log.Info("Repo: MergeServices called", "target_id", targetID, "source_id", sourceID)
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		logger.Log.Error("Failed to start transaction", "error", err)
+
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	var targetName string
+
+	query, args, err := sr.builder.
+		Select("name").
+		From("services").
+		Where("id = ?", targetID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if err = tx.QueryRow(query, args...).Scan(&targetName); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error("Repo: Target service not found", "id", targetID)
+
+			err = errormsgs.NotFound
+
+			return err
+		}
+
+		logger.Log.Error("Repo: Failed to lock target service", "error", err)
+
+		return fmt.Errorf("failed to lock target service: %w", err)
+	}
+
+	query, args, err = sr.builder.
+		Update("Subscriptions").
+		Set("service_id", targetID).
+		Set("service_name", targetName).
+		Set("version", squirrel.Expr("version + 1")).
+		Where("service_id = ?", sourceID).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build move query", "error", err)
+
+		return fmt.Errorf("failed to build move query: %w", err)
+	}
+
+	if _, err = tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to move subscriptions", "error", err)
+
+		return fmt.Errorf("failed to move subscriptions: %w", err)
+	}
+
+	query, args, err = sr.builder.
+		Delete("services").
+		Where("id = ?", sourceID).
+		Suffix("RETURNING to_json(aliases)").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build delete query", "error", err)
+
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	var sourceAliases textArray
+
+	if err = tx.QueryRow(query, args...).Scan(&sourceAliases); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error("Repo: Source service not found", "id", sourceID)
+
+			err = errormsgs.NotFound
+
+			return err
+		}
+
+		logger.Log.Error("Repo: Failed to delete source service", "error", err)
+
+		return fmt.Errorf("failed to delete source service: %w", err)
+	}
+
+	query, args, err = sr.builder.
+		Update("services").
+		Set("aliases", squirrel.Expr("ARRAY(SELECT DISTINCT a FROM unnest(aliases || ?::text[]) a ORDER BY a)", []string(sourceAliases))).
+		Where("id = ?", targetID).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build alias merge query", "error", err)
+
+		return fmt.Errorf("failed to build alias merge query: %w", err)
+	}
+
+	if _, err = tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to merge aliases", "error", err)
+
+		return fmt.Errorf("failed to merge aliases: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Repo: Services merged successfully", "target_id", targetID, "source_id", sourceID)
+
+	return nil
+}
@@ -0,0 +1,427 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// activeMonthsJoin expands a subscription into the months it was active inside the period.
+const activeMonthsJoin = "CROSS JOIN LATERAL generate_series(" +
+	"date_trunc('month', GREATEST(s.start_date, ?::date)), " +
+	"date_trunc('month', LEAST(COALESCE(s.end_date, ?::date), ?::date)), " +
+	"interval '1 month') AS m(month)"
+
+// exchangeRateJoin picks the latest rate dated on or before the end of each month.
+// The rate is NULL when the subscription currency cannot be converted.
+const exchangeRateJoin = "LEFT JOIN LATERAL (SELECT CASE WHEN s.currency = ? THEN 1::numeric ELSE (" +
+	"SELECT er.rate FROM exchange_rates er " +
+	"WHERE er.from_currency = s.currency AND er.to_currency = ? AND er.rate_date < m.month + interval '1 month' " +
+	"ORDER BY er.rate_date DESC LIMIT 1" +
+	") END AS rate) r ON true"
+
+// billableMonthSQL excludes months in which the subscription was still in
+// trial or paused on the last day of the month.
+const billableMonthSQL = "(s.trial_end_date IS NULL OR s.trial_end_date <= (m.month + interval '1 month - 1 day')::date) " +
+	"AND NOT EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = s.id " +
+	"AND p.paused_from <= (m.month + interval '1 month - 1 day')::date " +
+	"AND (p.resumed_on IS NULL OR p.resumed_on > (m.month + interval '1 month - 1 day')::date))"
+
+var aggregationColumns = map[entities.AggregationDimension]string{
+	entities.DimensionServiceName: "s.service_name",
+	entities.DimensionUserID:      "sh.user_id",
+	entities.DimensionMonth:       "m.month",
+	entities.DimensionTag:         "t.name",
+}
+
+// tagJoin repeats a subscription once per tag. Untagged subscriptions keep a
+// single row with a NULL tag.
+const tagJoin = "LEFT JOIN subscription_tags st ON st.subscription_id = s.id LEFT JOIN tags t ON t.id = st.tag_id"
+
+var aggregationMetrics = map[entities.AggregationMetric]string{
+	entities.MetricTotal:   "total",
+	entities.MetricCount:   "count",
+	entities.MetricAverage: "average",
+}
+
+// monthlyCostSQL mirrors entities.BillingPeriod.MonthlyCost for price and the
+// billing columns of the given table alias.
+func monthlyCostSQL(price, alias string) string {
+	if alias != "" {
+		alias += "."
+	}
+
+	return fmt.Sprintf(
+		"(%[2]s::numeric * CASE %[1]sbilling_period WHEN 'week' THEN 52.0 / 12 WHEN 'quarter' THEN 1.0 / 3 WHEN 'year' THEN 1.0 / 12 ELSE 1 END / %[1]sbilling_interval)",
+		alias, price,
+	)
+}
+
+// convertedMonthlyCostSQL uses the price in effect for the month joined by
+// priceJoin and counts only the share of the user joined by participantsSQL.
+var convertedMonthlyCostSQL = "(" + monthlyCostSQL("COALESCE(pc.price, s.price)", "s") + " * r.rate * sh.fraction)"
+
+// costBuilder selects one row per subscription, active month and paying user,
+// with the exchange rate for that month joined as r.rate. Filtering by user
+// keeps only the months that user shares in.
+func (sr *SubsRepo) costBuilder(filter entities.CostFilter, columns ...string) squirrel.SelectBuilder {
+	builder := sr.builder.
+		Select(columns...).
+		From("Subscriptions s").
+		JoinClause(activeMonthsJoin, filter.StartPeriod, filter.EndPeriod, filter.EndPeriod).
+		JoinClause(priceJoin).
+		JoinClause("CROSS JOIN "+participantsSQL).
+		JoinClause(exchangeRateJoin, filter.Currency, filter.Currency).
+		Where("s.start_date <= ?", filter.EndPeriod).
+		Where("s.deleted_at IS NULL").
+		Where(billableMonthSQL)
+
+	if filter.StartPeriod != nil {
+		builder = builder.Where("(s.end_date IS NULL OR s.end_date >= ?)", *filter.StartPeriod)
+	}
+
+	if filter.UserID != "" {
+		builder = builder.
+			Where(participantMatch, filter.UserID, filter.UserID).
+			Where("sh.user_id = ?", filter.UserID)
+	}
+
+	if filter.ServiceName != "" {
+		builder = builder.Where(serviceNameMatch("s", []string{filter.ServiceName}))
+	}
+
+	return builder
+}
+
+func (sr *SubsRepo) MissingExchangeRates(ctx context.Context, filter entities.CostFilter) ([]entities.MissingExchangeRate, error) {
+	logger.Log.Info("Repo: MissingExchangeRates called", "currency", filter.Currency)
+
+	query, args, err := sr.costBuilder(filter, "s.currency", "m.month").
+		Distinct().
+		Where("r.rate IS NULL").
+		OrderBy("m.month", "s.currency").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build missing rates query", "error", err)
+
+		return nil, fmt.Errorf("failed to build missing rates query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute missing rates query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute missing rates query: %w", err)
+	}
+
+	defer rows.Close()
+
+	missing := make([]entities.MissingExchangeRate, 0)
+
+	for rows.Next() {
+		rate := entities.MissingExchangeRate{To: filter.Currency}
+		if err := rows.Scan(&rate.From, &rate.Month); err != nil {
+			logger.Log.Error("Repo: Failed to scan missing rate row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan missing rate row: %w", err)
+		}
+
+		missing = append(missing, rate)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	return missing, nil
+}
+
+// SumSubscriptions returns the number of months each subscription was active
+// inside the filter period and its cost, based on the monthly-normalised price
+// in effect for each month.
+func (sr *SubsRepo) SumSubscriptions(ctx context.Context, filter entities.CostFilter) ([]entities.SubscriptionCost, error) {
+	logger.Log.Info("Repo: SumSubscriptions called", "user_id", filter.UserID, "service_name", filter.ServiceName, "currency", filter.Currency)
+
+	query, args, err := sr.costBuilder(filter,
+		"s.id", "s.service_name", "s.user_id", "s.price", "s.currency", "s.billing_period", "s.billing_interval",
+		"ROUND(SUM("+convertedMonthlyCostSQL+") / COUNT(DISTINCT m.month), 2)::float8",
+		"COUNT(DISTINCT m.month)",
+		"ROUND(SUM("+convertedMonthlyCostSQL+"), 2)::float8",
+	).
+		GroupBy("s.id").
+		OrderBy("s.user_id", "s.service_name", "s.id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build sum query", "error", err)
+
+		return nil, fmt.Errorf("failed to build sum query: %w", err)
+	}
+
+	logger.Log.Info("Repo: Executing sum query", "query", query, "args", args)
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute sum query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute sum query: %w", err)
+	}
+
+	defer rows.Close()
+
+	costs := make([]entities.SubscriptionCost, 0)
+
+	for rows.Next() {
+		var cost entities.SubscriptionCost
+		err := rows.Scan(
+			&cost.SubscriptionID, &cost.ServiceName, &cost.UserID, &cost.Price, &cost.Currency, &cost.BillingPeriod, &cost.BillingInterval,
+			&cost.MonthlyCost, &cost.Months, &cost.Cost,
+		)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to scan sum row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan sum row: %w", err)
+		}
+
+		costs = append(costs, cost)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: SumSubscriptions completed", "user_id", filter.UserID, "service_name", filter.ServiceName, "count", len(costs))
+
+	return costs, nil
+}
+
+func (sr *SubsRepo) MonthlySubscriptionCosts(ctx context.Context, filter entities.CostFilter) ([]entities.MonthlyCost, error) {
+	logger.Log.Info("Repo: MonthlySubscriptionCosts called", "user_id", filter.UserID, "service_name", filter.ServiceName, "currency", filter.Currency)
+
+	activeInMonth := squirrel.And{
+		squirrel.Expr("s.start_date < m.month + interval '1 month'"),
+		squirrel.Expr("(s.end_date IS NULL OR s.end_date >= m.month)"),
+		squirrel.Expr("s.deleted_at IS NULL"),
+		squirrel.Expr(billableMonthSQL),
+	}
+
+	if filter.UserID != "" {
+		activeInMonth = append(activeInMonth, squirrel.Expr(participantMatch, filter.UserID, filter.UserID))
+	}
+
+	participantsJoin := squirrel.Expr("LEFT JOIN " + participantsSQL + " ON true")
+	if filter.UserID != "" {
+		participantsJoin = squirrel.Expr("LEFT JOIN "+participantsSQL+" ON sh.user_id = ?", filter.UserID)
+	}
+
+	if filter.ServiceName != "" {
+		activeInMonth = append(activeInMonth, serviceNameMatch("s", []string{filter.ServiceName}))
+	}
+
+	joinCond, joinArgs, err := activeInMonth.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build join condition", "error", err)
+
+		return nil, fmt.Errorf("failed to build monthly query: %w", err)
+	}
+
+	query, args, err := sr.builder.
+		Select("m.month", "COALESCE(ROUND(SUM("+convertedMonthlyCostSQL+"), 2), 0)::float8", "COUNT(DISTINCT s.id) FILTER (WHERE sh.user_id IS NOT NULL)").
+		Prefix(
+			"WITH months AS (SELECT generate_series(date_trunc('month', ?::date), date_trunc('month', ?::date), interval '1 month') AS month)",
+			filter.StartPeriod, filter.EndPeriod,
+		).
+		From("months m").
+		LeftJoin("Subscriptions s ON "+joinCond, joinArgs...).
+		JoinClause(priceJoin).
+		JoinClause(participantsJoin).
+		JoinClause(exchangeRateJoin, filter.Currency, filter.Currency).
+		GroupBy("m.month").
+		OrderBy("m.month").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build monthly query", "error", err)
+
+		return nil, fmt.Errorf("failed to build monthly query: %w", err)
+	}
+
+	logger.Log.Info("Repo: Executing monthly query", "query", query, "args", args)
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute monthly query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute monthly query: %w", err)
+	}
+
+	defer rows.Close()
+
+	costs := make([]entities.MonthlyCost, 0)
+
+	for rows.Next() {
+		var cost entities.MonthlyCost
+		if err := rows.Scan(&cost.Month, &cost.Total, &cost.ActiveCount); err != nil {
+			logger.Log.Error("Repo: Failed to scan monthly row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan monthly row: %w", err)
+		}
+
+		costs = append(costs, cost)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: MonthlySubscriptionCosts completed", "user_id", filter.UserID, "service_name", filter.ServiceName, "months", len(costs))
+
+	return costs, nil
+}
+
+// AggregateSubscriptions expands every subscription into the months it was
+// active inside the spec period and groups them by the requested dimensions.
+func (sr *SubsRepo) AggregateSubscriptions(ctx context.Context, spec entities.AggregationSpec) ([]entities.AggregationRow, error) {
+	logger.Log.Info("Repo: AggregateSubscriptions called", "group_by", spec.GroupBy, "user_id", spec.UserID, "service_name", spec.ServiceName)
+
+	groupBy := make([]string, 0, len(spec.GroupBy))
+
+	for _, dimension := range spec.GroupBy {
+		column, ok := aggregationColumns[dimension]
+		if !ok {
+			return nil, fmt.Errorf("unknown aggregation dimension %q", dimension)
+		}
+
+		groupBy = append(groupBy, column)
+	}
+
+	metric, ok := aggregationMetrics[spec.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation metric %q", spec.SortBy)
+	}
+
+	direction := "ASC"
+	if spec.Descending {
+		direction = "DESC"
+	}
+
+	columns := append(append([]string{}, groupBy...),
+		"COALESCE(ROUND(SUM("+convertedMonthlyCostSQL+"), 2), 0)::float8 AS total",
+		"COUNT(DISTINCT s.id) AS count",
+		"COALESCE(ROUND(SUM("+convertedMonthlyCostSQL+") / NULLIF(COUNT(DISTINCT s.id), 0), 2), 0)::float8 AS average",
+	)
+
+	builder := sr.costBuilder(spec.CostFilter, columns...)
+
+	if slices.Contains(spec.GroupBy, entities.DimensionTag) {
+		builder = builder.JoinClause(tagJoin)
+	}
+
+	if len(groupBy) > 0 {
+		builder = builder.GroupBy(groupBy...)
+	}
+
+	builder = builder.OrderBy(metric + " " + direction)
+
+	if spec.Limit > 0 {
+		builder = builder.Limit(spec.Limit)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build aggregate query", "error", err)
+
+		return nil, fmt.Errorf("failed to build aggregate query: %w", err)
+	}
+
+	logger.Log.Info("Repo: Executing aggregate query", "query", query, "args", args)
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute aggregate query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute aggregate query: %w", err)
+	}
+
+	defer rows.Close()
+
+	result := make([]entities.AggregationRow, 0)
+
+	for rows.Next() {
+		var row entities.AggregationRow
+
+		dest := make([]any, 0, len(spec.GroupBy)+3)
+
+		for _, dimension := range spec.GroupBy {
+			switch dimension {
+			case entities.DimensionServiceName:
+				dest = append(dest, &row.ServiceName)
+			case entities.DimensionUserID:
+				dest = append(dest, &row.UserID)
+			case entities.DimensionMonth:
+				dest = append(dest, &row.Month)
+			case entities.DimensionTag:
+				dest = append(dest, &row.Tag)
+			}
+		}
+
+		dest = append(dest, &row.Total, &row.Count, &row.Average)
+
+		if err := rows.Scan(dest...); err != nil {
+			logger.Log.Error("Repo: Failed to scan aggregate row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan aggregate row: %w", err)
+		}
+
+		result = append(result, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: AggregateSubscriptions completed", "groups", len(result))
+
+	return result, nil
+}
@@ -1,16 +1,41 @@
 package repo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/agl/online_subs/internal/domain/entities"
 	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/internal/tenant"
 	"github.com/agl/online_subs/pkg/logger"
 )
 
+var subscriptionColumns = []string{
+	"id", "service_id", "service_name", "price", "currency", "billing_period", "billing_interval", "user_id", "start_date", "end_date",
+	"status", "trial_end_date", "status_changed_at", "cancelled_at", "split_rule", "deleted_at", "version", subscriptionTagsSQL,
+}
+
+// notDeleted matches the subscriptions that have not been soft-deleted.
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
+// subscriptionsInsertBatchSize keeps multi-row inserts well under the 65535
+// bind parameters Postgres allows per statement.
+const subscriptionsInsertBatchSize = 1000
+
+var sortColumns = map[entities.SortField]string{
+	entities.SortByPrice:       "price",
+	entities.SortByStartDate:   "start_date",
+	entities.SortByServiceName: "service_name",
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 type SubsRepo struct {
 	db      *sql.DB
 	builder squirrel.StatementBuilderType
@@ -23,26 +48,29 @@ func NewSubsRepo(db *sql.DB) *SubsRepo {
 	}
 }
 
-func (sr *SubsRepo) CreateSubscription(sub entities.Subscription) error {
-	logger.Log.Info("Repo: CreateSubscription called", "user_id", sub.UserID, "service_name", sub.ServiceName)
+// scanSubscription scans subscriptionColumns followed by any extra selected columns.
+func scanSubscription(row rowScanner, extra ...any) (entities.Subscription, error) {
+	var sub entities.Subscription
+	var tags textArray
+	dest := []any{
+		&sub.ID, &sub.ServiceID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingPeriod, &sub.BillingInterval, &sub.UserID, &sub.StartDate, &sub.EndDate,
+		&sub.Status, &sub.TrialEndDate, &sub.StatusChangedAt, &sub.CancelledAt, &sub.SplitRule, &sub.DeletedAt, &sub.Version, &tags,
+	}
+	err := row.Scan(append(dest, extra...)...)
 
-	query, args, err := sr.builder.
-		Insert("Subscriptions").
-		Columns("service_name", "price", "user_id", "start_date", "end_date").
-		Values(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).
-		ToSql()
+	sub.Tags = tags
 
-	if err != nil {
-		logger.Log.Error("Repo: Failed to build insert query", "error", err)
+	return sub, err
+}
 
-		return fmt.Errorf("failed to build query: %w", err)
-	}
+func (sr *SubsRepo) CreateSubscription(ctx context.Context, sub entities.Subscription) (string, error) {
+	logger.Log.Info("Repo: CreateSubscription called", "user_id", sub.UserID, "service_name", sub.ServiceName)
 
-	tx, err := sr.db.Begin()
+	tx, err := beginTenant(ctx, sr.db)
 	if err != nil {
 		logger.Log.Error("Failed to start transaction", "error", err)
 
-		return err
+		return "", err
 	}
 
 	defer func() {
@@ -53,32 +81,162 @@ func (sr *SubsRepo) CreateSubscription(sub entities.Subscription) error {
 		}
 	}()
 
-	_, err = tx.Exec(query, args...)
+	var ids []string
+
+	ids, err = sr.createSubscriptions(ctx, tx, []entities.Subscription{sub})
 	if err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return "", err
+	}
+
+	logger.Log.Info("Repo: Subscription created successfully", "id", ids[0], "user_id", sub.UserID, "service_name", sub.ServiceName)
+
+	return ids[0], nil
+}
+
+// createSubscriptions inserts subs with multi-row inserts, along with their
+// initial prices, tags and audit entries, and returns their IDs in order. The
+// IDs are allocated up front so that they do not depend on the order
+// RETURNING yields rows in.
+func (sr *SubsRepo) createSubscriptions(ctx context.Context, tx *sql.Tx, subs []entities.Subscription) ([]string, error) {
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	ids, err := allocateSubscriptionIDs(tx, len(subs))
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(subs); start += subscriptionsInsertBatchSize {
+		end := min(start+subscriptionsInsertBatchSize, len(subs))
+
+		if err := sr.insertSubscriptions(ctx, tx, subs[start:end], ids[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// insertSubscriptions writes one multi-row insert worth of subs under ids.
+func (sr *SubsRepo) insertSubscriptions(ctx context.Context, tx *sql.Tx, subs []entities.Subscription, ids []string) error {
+	insert := sr.builder.
+		Insert("Subscriptions").
+		Columns("id", "service_id", "service_name", "price", "currency", "billing_period", "billing_interval", "user_id", "start_date", "end_date", "status", "trial_end_date", "split_rule")
+
+	prices := sr.builder.
+		Insert("subscription_price_changes").
+		Columns("subscription_id", "effective_month", "price")
+
+	// Subscriptions sharing the same tags get them in one go.
+	tagged := make(map[string][]string)
+	tagLists := make(map[string][]string)
+
+	for i, sub := range subs {
+		insert = insert.Values(ids[i], sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingInterval, sub.UserID, sub.StartDate, sub.EndDate, sub.Status, sub.TrialEndDate, sub.SplitRule)
+		prices = prices.Values(ids[i], squirrel.Expr("date_trunc('month', ?::date)", sub.StartDate), sub.Price)
+
+		if len(sub.Tags) > 0 {
+			key := strings.Join(sub.Tags, "\x00")
+			tagged[key] = append(tagged[key], ids[i])
+			tagLists[key] = sub.Tags
+		}
+	}
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build insert query", "error", err)
+
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
 		logger.Log.Error("Repo: Failed to execute insert", "error", err)
 
 		return fmt.Errorf("failed to create subscription: %w", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+	query, args, err = prices.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build price insert query", "error", err)
+
+		return fmt.Errorf("failed to build price insert query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to record initial price", "error", err)
+
+		return fmt.Errorf("failed to record initial price: %w", err)
+	}
+
+	for key, subscriptionIDs := range tagged {
+		if err := sr.setSubscriptionTags(tx, subscriptionIDs, tagLists[key]); err != nil {
+			return err
+		}
+	}
 
+	after, err := sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": ids})
+	if err != nil {
 		return err
 	}
 
-	logger.Log.Info("Repo: Subscription created successfully", "user_id", sub.UserID, "service_name", sub.ServiceName)
+	return sr.auditSubscriptions(ctx, tx, entities.AuditCreate, nil, after)
+}
 
-	return nil
+// allocateSubscriptionIDs generates count new subscription IDs.
+func allocateSubscriptionIDs(tx *sql.Tx, count int) ([]string, error) {
+	rows, err := tx.Query("SELECT uuid_generate_v4()::text FROM generate_series(1, $1)", count)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to allocate subscription IDs", "error", err)
+
+		return nil, fmt.Errorf("failed to allocate subscription IDs: %w", err)
+	}
+
+	defer rows.Close()
+
+	ids := make([]string, 0, count)
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			logger.Log.Error("Repo: Failed to scan subscription ID", "error", err)
+
+			return nil, fmt.Errorf("failed to scan subscription ID: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	return ids, nil
 }
 
-func (sr *SubsRepo) GetSubscriptionByUserUUID(userUUID string) (entities.Subscription, error) {
-	logger.Log.Info("Repo: GetSubscriptionByUserUUID called", "user_id", userUUID)
+// GetSubscriptionByID returns the subscription, or NotFound when it has been
+// deleted and includeDeleted is not set.
+func (sr *SubsRepo) GetSubscriptionByID(ctx context.Context, id string, includeDeleted bool) (entities.Subscription, error) {
+	logger.Log.Info("Repo: GetSubscriptionByID called", "id", id, "include_deleted", includeDeleted)
 
-	query, args, err := sr.builder.
-		Select("service_name", "price", "user_id", "start_date", "end_date").
+	builder := sr.builder.
+		Select(subscriptionColumns...).
 		From("Subscriptions").
-		Where("user_id = ?", userUUID).
-		ToSql()
+		Where("id = ?", id)
+
+	if !includeDeleted {
+		builder = builder.Where(notDeleted)
+	}
+
+	query, args, err := builder.ToSql()
 
 	if err != nil {
 		logger.Log.Error("Repo: Failed to build select query", "error", err)
@@ -86,16 +244,19 @@ func (sr *SubsRepo) GetSubscriptionByUserUUID(userUUID string) (entities.Subscri
 		return entities.Subscription{}, fmt.Errorf("couldn't make the query: %w", err)
 	}
 
-	logger.Log.Info("Repo: Executing query for GetSubscriptionByUserUUID", "query", query, "args", args)
+	logger.Log.Info("Repo: Executing query for GetSubscriptionByID", "query", query, "args", args)
 
-	row := sr.db.QueryRow(query, args...)
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return entities.Subscription{}, err
+	}
 
-	var sub entities.Subscription
-	err = row.Scan(&sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+	defer rollback(tx)
 
+	sub, err := scanSubscription(tx.QueryRow(query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.Log.Error("Repo: Subscription not found", "user_id", userUUID)
+			logger.Log.Error("Repo: Subscription not found", "id", id)
 
 			return entities.Subscription{}, errormsgs.NotFound
 		}
@@ -105,46 +266,160 @@ func (sr *SubsRepo) GetSubscriptionByUserUUID(userUUID string) (entities.Subscri
 		return entities.Subscription{}, fmt.Errorf("couldn't extract the entity: %w", err)
 	}
 
-	logger.Log.Info("Repo: Subscription fetched successfully", "user_id", userUUID, "service_name", sub.ServiceName)
+	logger.Log.Info("Repo: Subscription fetched successfully", "id", id, "service_name", sub.ServiceName)
 
 	return sub, nil
 }
 
-func (sr *SubsRepo) GetSubscriptionFiltered(subscription entities.Subscription) ([]entities.Subscription, error) {
-	logger.Log.Info("Repo: GetSubscriptionFiltered called", "user_id", subscription.UserID)
+func (sr *SubsRepo) GetSubscriptionsByUserUUID(ctx context.Context, userUUID string) ([]entities.Subscription, error) {
+	logger.Log.Info("Repo: GetSubscriptionsByUserUUID called", "user_id", userUUID)
+
+	subscriptions, err := sr.GetSubscriptionFiltered(ctx,
+		entities.SubscriptionFilter{UserID: userUUID},
+		entities.PageRequest{SortBy: entities.SortByStartDate},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subscriptions) == 0 {
+		logger.Log.Error("Repo: Subscriptions not found", "user_id", userUUID)
+
+		return nil, errormsgs.NotFound
+	}
+
+	return subscriptions, nil
+}
+
+func (sr *SubsRepo) filterBuilder(filter entities.SubscriptionFilter, columns ...string) squirrel.SelectBuilder {
+	logger.Log.Info("Repo: Building query for GetSubscriptionFiltered", "user_id", filter.UserID, "service_names", filter.ServiceNames)
+
+	builder := sr.builder.Select(columns...).From("Subscriptions")
 
-	logger.Log.Info("Repo: Building query for GetSubscriptionFiltered", "user_id", subscription.UserID, "service_name", subscription.ServiceName, "price", subscription.Price)
+	if !filter.IncludeDeleted {
+		builder = builder.Where(notDeleted)
+	}
+
+	if filter.UserID != "" {
+		logger.Log.Info("Repo: Filtering by user_id", "user_id", filter.UserID)
 
-	builder := sr.builder.Select("service_name", "price", "user_id", "start_date", "end_date").From("Subscriptions")
+		builder = builder.Where("user_id = ?", filter.UserID)
+	}
 
-	if subscription.UserID != "" {
-		logger.Log.Info("Repo: Filtering by user_id", "user_id", subscription.UserID)
+	if filter.MonthlyCostMin != 0 {
+		logger.Log.Info("Repo: Filtering by monthly cost >=", "price", filter.MonthlyCostMin)
 
-		builder = builder.Where("user_id = ?", subscription.UserID)
+		builder = builder.Where(monthlyCostSQL("price", "")+" >= ?", filter.MonthlyCostMin)
 	}
 
-	if subscription.Price != 0 {
-		logger.Log.Info("Repo: Filtering by price >=", "price", subscription.Price)
+	if filter.PriceMin != nil {
+		logger.Log.Info("Repo: Filtering by price >=", "price_min", *filter.PriceMin)
 
-		builder = builder.Where("price >= ?", subscription.Price)
+		builder = builder.Where("price >= ?", *filter.PriceMin)
 	}
 
-	if subscription.ServiceName != "" {
-		logger.Log.Info("Repo: Filtering by service_name", "service_name", subscription.ServiceName)
+	if filter.PriceMax != nil {
+		logger.Log.Info("Repo: Filtering by price <=", "price_max", *filter.PriceMax)
 
-		builder = builder.Where("service_name = ?", subscription.ServiceName)
+		builder = builder.Where("price <= ?", *filter.PriceMax)
 	}
 
-	if !subscription.StartDate.IsZero() {
-		logger.Log.Info("Repo: Filtering by start_date >=", "start_date", subscription.StartDate)
+	if filter.Currency != "" {
+		logger.Log.Info("Repo: Filtering by currency", "currency", filter.Currency)
 
-		builder = builder.Where("start_date >= ?", subscription.StartDate)
+		builder = builder.Where("currency = ?", filter.Currency)
 	}
 
-	if !subscription.EndDate.IsZero() {
-		logger.Log.Info("Repo: Filtering by end_date <=", "end_date", subscription.EndDate)
+	if filter.BillingPeriod != "" {
+		logger.Log.Info("Repo: Filtering by billing_period", "billing_period", filter.BillingPeriod)
 
-		builder = builder.Where("end_date <= ?", subscription.EndDate)
+		builder = builder.Where("billing_period = ?", filter.BillingPeriod)
+	}
+
+	if len(filter.SearchTerms) > 0 {
+		logger.Log.Info("Repo: Filtering by service name search", "terms", filter.SearchTerms)
+
+		builder = builder.Where(searchMatch(filter.SearchTerms))
+	}
+
+	if len(filter.ServiceNames) > 0 {
+		logger.Log.Info("Repo: Filtering by service_name", "service_names", filter.ServiceNames)
+
+		builder = builder.Where(serviceNameMatch("", filter.ServiceNames))
+	}
+
+	if len(filter.Tags) > 0 {
+		logger.Log.Info("Repo: Filtering by tags", "tags", filter.Tags)
+
+		builder = builder.Where(tagsMatch(filter.Tags))
+	}
+
+	if filter.ActiveOn != nil {
+		logger.Log.Info("Repo: Filtering by active on", "active_on", *filter.ActiveOn)
+
+		builder = builder.Where("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", *filter.ActiveOn, *filter.ActiveOn)
+	}
+
+	if filter.StartDateFrom != nil {
+		logger.Log.Info("Repo: Filtering by start_date >=", "start_date", *filter.StartDateFrom)
+
+		builder = builder.Where("start_date >= ?", *filter.StartDateFrom)
+	}
+
+	if filter.StartDateTo != nil {
+		logger.Log.Info("Repo: Filtering by start_date <=", "start_date", *filter.StartDateTo)
+
+		builder = builder.Where("start_date <= ?", *filter.StartDateTo)
+	}
+
+	if filter.EndDateTo != nil {
+		logger.Log.Info("Repo: Filtering by end_date <=", "end_date", *filter.EndDateTo)
+
+		builder = builder.Where("end_date <= ?", *filter.EndDateTo)
+	}
+
+	return builder
+}
+
+// GetSubscriptionFiltered returns the subscriptions matching the filter in keyset order.
+func (sr *SubsRepo) GetSubscriptionFiltered(ctx context.Context, filter entities.SubscriptionFilter, page entities.PageRequest) ([]entities.Subscription, error) {
+	logger.Log.Info("Repo: GetSubscriptionFiltered called", "user_id", filter.UserID, "sort", page.SortBy, "limit", page.Limit)
+
+	searching := len(filter.SearchTerms) > 0
+	rankSQL, rankArgs := searchRankSQL(filter.SearchTerms)
+
+	sortColumn, ok := sortColumns[page.SortBy]
+
+	var sortArgs []any
+
+	if page.SortBy == entities.SortByRelevance && searching {
+		sortColumn, sortArgs, ok = rankSQL, rankArgs, true
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", page.SortBy)
+	}
+
+	direction, comparison := "ASC", ">"
+	if page.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	builder := sr.filterBuilder(filter, subscriptionColumns...)
+
+	if searching {
+		builder = builder.Column(squirrel.Expr(rankSQL, rankArgs...))
+	}
+
+	builder = builder.OrderByClause(sortColumn+" "+direction+", id "+direction, sortArgs...)
+
+	if page.After != nil {
+		keysetArgs := append(append([]any{}, sortArgs...), page.After.Value, page.After.ID)
+		builder = builder.Where("("+sortColumn+", id) "+comparison+" (?, ?)", keysetArgs...)
+	}
+
+	if page.Limit > 0 {
+		builder = builder.Limit(page.Limit)
 	}
 
 	query, args, err := builder.ToSql()
@@ -156,7 +431,14 @@ func (sr *SubsRepo) GetSubscriptionFiltered(subscription entities.Subscription)
 
 	logger.Log.Info("Repo: Executing filtered select query", "query", query, "args", args)
 
-	rows, err := sr.db.Query(query, args...)
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
 	if err != nil {
 		logger.Log.Error("Repo: Failed to execute select query", "error", err)
 
@@ -168,15 +450,23 @@ func (sr *SubsRepo) GetSubscriptionFiltered(subscription entities.Subscription)
 	subscriptions := make([]entities.Subscription, 0)
 
 	for rows.Next() {
-		var sub entities.Subscription
-		err := rows.Scan(&sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+		var relevance float64
+
+		extra := make([]any, 0, 1)
+		if searching {
+			extra = append(extra, &relevance)
+		}
+
+		sub, err := scanSubscription(rows, extra...)
 		if err != nil {
 			logger.Log.Error("Repo: Failed to scan row", "error", err)
 
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		logger.Log.Info("Repo: Row scanned", "user_id", sub.UserID, "service_name", sub.ServiceName)
+		sub.Relevance = relevance
+
+		logger.Log.Info("Repo: Row scanned", "id", sub.ID, "user_id", sub.UserID, "service_name", sub.ServiceName)
 
 		subscriptions = append(subscriptions, sub)
 	}
@@ -187,59 +477,108 @@ func (sr *SubsRepo) GetSubscriptionFiltered(subscription entities.Subscription)
 		return nil, fmt.Errorf("error in rows iteration: %w", err)
 	}
 
-	if len(subscriptions) == 0 {
-		logger.Log.Error("Repo: Subscriptions not found", "filter", subscription)
-
-		return nil, errormsgs.NotFound
-	}
-
 	logger.Log.Info("Repo: Subscriptions fetched successfully", "count", len(subscriptions))
 
 	return subscriptions, nil
 }
 
-func (sr *SubsRepo) UpdateSubscriptionByUserUUID(subscription entities.Subscription) error {
-	logger.Log.Info("Repo: UpdateSubscriptionByUserUUID called", "user_id", subscription.UserID)
+func (sr *SubsRepo) CountSubscriptionsFiltered(ctx context.Context, filter entities.SubscriptionFilter) (int, error) {
+	logger.Log.Info("Repo: CountSubscriptionsFiltered called", "user_id", filter.UserID)
+
+	query, args, err := sr.filterBuilder(filter, "COUNT(*)").ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build count query", "error", err)
+
+		return 0, fmt.Errorf("failed to build count query: %w", err)
+	}
 
-	builder := sr.builder.Update("Subscriptions")
+	var total int
 
-	fieldsToUpdate := false
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return 0, err
+	}
 
-	if subscription.ServiceName != "" {
-		builder = builder.Set("service_name", subscription.ServiceName)
-		fieldsToUpdate = true
+	defer rollback(tx)
+
+	if err := tx.QueryRow(query, args...).Scan(&total); err != nil {
+		logger.Log.Error("Repo: Failed to execute count query", "error", err)
+
+		return 0, fmt.Errorf("failed to execute count query: %w", err)
 	}
 
-	if subscription.Price != 0 {
-		builder = builder.Set("price", subscription.Price)
-		fieldsToUpdate = true
+	logger.Log.Info("Repo: Subscriptions counted successfully", "total", total)
+
+	return total, nil
+}
+
+// StreamSubscriptions calls each for every subscription matching the filter,
+// ordered by start date, as rows arrive from the cursor, so the result is
+// never held in memory. An error from each stops the stream and is returned.
+func (sr *SubsRepo) StreamSubscriptions(ctx context.Context, filter entities.SubscriptionFilter, each func(entities.Subscription) error) error {
+	logger.Log.Info("Repo: StreamSubscriptions called", "user_id", filter.UserID)
+
+	query, args, err := sr.filterBuilder(filter, subscriptionColumns...).
+		OrderBy("start_date", "id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build select query", "error", err)
+
+		return fmt.Errorf("failed to build query: %w", err)
 	}
 
-	if !subscription.StartDate.IsZero() {
-		builder = builder.Set("start_date", subscription.StartDate)
-		fieldsToUpdate = true
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return err
 	}
 
-	if !subscription.EndDate.IsZero() {
-		builder = builder.Set("end_date", *subscription.EndDate)
-		fieldsToUpdate = true
+	defer rollback(tx)
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute select query", "error", err)
+
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	if !fieldsToUpdate {
-		logger.Log.Info("Repo: No fields to update for user", "user_id", subscription.UserID)
+	defer rows.Close()
+
+	count := 0
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to scan subscription", "error", err)
+
+			return fmt.Errorf("couldn't extract the entity: %w", err)
+		}
 
-		return nil
+		if err := each(sub); err != nil {
+			return err
+		}
+
+		count++
 	}
 
-	builder = builder.Where("user_id = ?", subscription.UserID)
-	query, args, err := builder.ToSql()
-	if err != nil {
-		logger.Log.Error("Repo: Failed to build update query", "error", err)
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
 
-		return fmt.Errorf("failed to build update query: %w", err)
+		return fmt.Errorf("error in rows iteration: %w", err)
 	}
 
-	tx, err := sr.db.Begin()
+	logger.Log.Info("Repo: Subscriptions streamed successfully", "count", count)
+
+	return nil
+}
+
+// ReplaceSubscriptions overwrites the subscriptions with the given state in
+// one transaction. They must be at the expected versions. A non-zero
+// PriceEffectiveMonth records Price in the price history from that month.
+func (sr *SubsRepo) ReplaceSubscriptions(ctx context.Context, subscriptions []entities.Subscription, expected entities.Versions) error {
+	logger.Log.Info("Repo: ReplaceSubscriptions called", "count", len(subscriptions))
+
+	tx, err := beginTenant(ctx, sr.db)
 	if err != nil {
 		logger.Log.Error("Failed to start transaction", "error", err)
 
@@ -254,24 +593,140 @@ func (sr *SubsRepo) UpdateSubscriptionByUserUUID(subscription entities.Subscript
 		}
 	}()
 
-	result, err := tx.Exec(query, args...)
+	if err = sr.replaceSubscriptions(ctx, tx, subscriptions, expected); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Repo: Subscriptions replaced successfully", "count", len(subscriptions))
+
+	return nil
+}
+
+func (sr *SubsRepo) replaceSubscriptions(ctx context.Context, tx *sql.Tx, subscriptions []entities.Subscription, expected entities.Versions) error {
+	ids := make([]string, 0, len(subscriptions))
+
+	for _, subscription := range subscriptions {
+		ids = append(ids, subscription.ID)
+	}
+
+	before, err := sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": ids, "s.deleted_at": nil})
 	if err != nil {
+		return err
+	}
+
+	if len(before) != len(ids) {
+		logger.Log.Error("Repo: Subscriptions not found for replace", "expected", len(ids), "found", len(before))
+
+		return errormsgs.NotFound
+	}
+
+	if err := checkVersions(before, expected); err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if err := sr.replaceSubscription(tx, subscription); err != nil {
+			return err
+		}
+	}
+
+	after, err := sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": ids})
+	if err != nil {
+		return err
+	}
+
+	return sr.auditSubscriptions(ctx, tx, entities.AuditUpdate, before, after)
+}
+
+func (sr *SubsRepo) replaceSubscription(tx *sql.Tx, subscription entities.Subscription) error {
+	where := squirrel.Eq{"id": subscription.ID}
+
+	builder := sr.builder.
+		Update("Subscriptions").
+		Set("service_id", subscription.ServiceID).
+		Set("service_name", subscription.ServiceName).
+		Set("currency", subscription.Currency).
+		Set("billing_period", subscription.BillingPeriod).
+		Set("billing_interval", subscription.BillingInterval).
+		Set("start_date", subscription.StartDate).
+		Set("end_date", subscription.EndDate).
+		Set("split_rule", subscription.SplitRule).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(where)
+
+	if !subscription.PriceEffectiveMonth.IsZero() {
+		if err := sr.appendPriceChange(tx, subscription.Price, subscription.PriceEffectiveMonth, where); err != nil {
+			return err
+		}
+
+		builder = builder.Set("price", squirrel.Expr(currentPriceSQL))
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build update query", "error", err)
+
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
 		logger.Log.Error("Repo: Failed to execute update", "error", err)
 
 		return fmt.Errorf("failed to update subscription: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return sr.setSubscriptionTags(tx, []string{subscription.ID}, subscription.Tags)
+}
+
+func (sr *SubsRepo) DeleteSubscriptionByID(ctx context.Context, id string, expected entities.Versions) error {
+	logger.Log.Info("Repo: DeleteSubscriptionByID called", "id", id)
+
+	return sr.deleteSubscriptions(ctx, squirrel.Eq{"id": id, "deleted_at": nil}, expected)
+}
+
+func (sr *SubsRepo) DeleteSubscriptionByUserUUID(ctx context.Context, userUUID string, expected entities.Versions) error {
+	logger.Log.Info("Repo: DeleteSubscriptionByUserUUID called", "user_id", userUUID)
+
+	return sr.deleteSubscriptions(ctx, squirrel.Eq{"user_id": userUUID, "deleted_at": nil}, expected)
+}
+
+// deleteSubscriptions soft-deletes the subscriptions matching where, which
+// must be at the expected versions; the retention purge removes them later.
+func (sr *SubsRepo) deleteSubscriptions(ctx context.Context, where squirrel.Eq, expected entities.Versions) error {
+	tx, err := beginTenant(ctx, sr.db)
 	if err != nil {
-		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
-		
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		logger.Log.Error("Failed to start transaction", "error", err)
+
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	var rowsAffected int64
+
+	rowsAffected, err = sr.softDeleteSubscriptions(ctx, tx, where, expected)
+	if err != nil {
+		return err
 	}
 
 	if rowsAffected == 0 {
-		logger.Log.Error("Repo: No subscription found for update", "user_id", subscription.UserID)
+		logger.Log.Error("Repo: No subscription found for delete", "where", where)
+
+		err = errormsgs.NotFound
 
-		return fmt.Errorf("no subscription found for user %s", subscription.UserID)
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -280,26 +735,77 @@ func (sr *SubsRepo) UpdateSubscriptionByUserUUID(subscription entities.Subscript
 		return err
 	}
 
-	logger.Log.Info("Repo: Subscription updated successfully", "user_id", subscription.UserID)
-	
+	logger.Log.Info("Repo: Subscription deleted successfully", "where", where, "rows_affected", rowsAffected)
+
 	return nil
 }
 
-func (sr *SubsRepo) DeleteSubscriptionByUserUUID(userUUID string) error {
-	logger.Log.Info("Repo: DeleteSubscriptionByUserUUID called", "user_id", userUUID)
-
+// softDeleteSubscriptions marks the subscriptions matching where as deleted
+// and returns how many there were.
+func (sr *SubsRepo) softDeleteSubscriptions(ctx context.Context, tx *sql.Tx, where squirrel.Eq, expected entities.Versions) (int64, error) {
 	query, args, err := sr.builder.
-		Delete("Subscriptions").
-		Where("user_id = ?", userUUID).
+		Update("Subscriptions").
+		Set("deleted_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(where).
 		ToSql()
 
 	if err != nil {
 		logger.Log.Error("Repo: Failed to build delete query", "error", err)
 
-		return fmt.Errorf("failed to build delete query: %w", err)
+		return 0, fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	before, err := sr.snapshotSubscriptions(tx, where)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := checkVersions(before, expected); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute delete", "error", err)
+
+		return 0, fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if err := sr.auditSubscriptions(ctx, tx, entities.AuditDelete, before, nil); err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, nil
+}
+
+// RestoreSubscription undoes the deletion of a subscription not yet purged.
+func (sr *SubsRepo) RestoreSubscription(ctx context.Context, id string) error {
+	logger.Log.Info("Repo: RestoreSubscription called", "id", id)
+
+	where := squirrel.And{squirrel.Eq{"id": id}, squirrel.NotEq{"deleted_at": nil}}
+
+	query, args, err := sr.builder.
+		Update("Subscriptions").
+		Set("deleted_at", nil).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(where).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build restore query", "error", err)
+
+		return fmt.Errorf("failed to build restore query: %w", err)
 	}
 
-	tx, err := sr.db.Begin()
+	tx, err := beginTenant(ctx, sr.db)
 	if err != nil {
 		logger.Log.Error("Failed to start transaction", "error", err)
 
@@ -314,24 +820,34 @@ func (sr *SubsRepo) DeleteSubscriptionByUserUUID(userUUID string) error {
 		}
 	}()
 
-	result, err := tx.Exec(query, args...)
+	before, err := sr.snapshotSubscriptions(tx, where)
 	if err != nil {
-		logger.Log.Error("Repo: Failed to execute delete", "error", err)
+		return err
+	}
+
+	if len(before) == 0 {
+		logger.Log.Error("Repo: No deleted subscription found for restore", "id", id)
+
+		err = errormsgs.NotFound
 
-		return fmt.Errorf("failed to delete subscription: %w", err)
+		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+	if _, err = tx.Exec(query, args...); err != nil {
+		logger.Log.Error("Repo: Failed to execute restore", "error", err)
 
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return fmt.Errorf("failed to restore subscription: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		logger.Log.Error("Repo: No subscription found for delete", "user_id", userUUID)
+	var after []subscriptionSnapshot
 
-		return fmt.Errorf("no subscription found for user %s", userUUID)
+	after, err = sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": id})
+	if err != nil {
+		return err
+	}
+
+	if err = sr.auditSubscriptions(ctx, tx, entities.AuditRestore, before, after); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -340,49 +856,182 @@ func (sr *SubsRepo) DeleteSubscriptionByUserUUID(userUUID string) error {
 		return err
 	}
 
-	logger.Log.Info("Repo: Subscription deleted successfully", "user_id", userUUID)
+	logger.Log.Info("Repo: Subscription restored successfully", "id", id)
 
 	return nil
 }
 
-func (sr *SubsRepo) SumSubscriptions(userID, serviceName string, startPeriod, endPeriod *time.Time) (int, error) {
-	logger.Log.Info("Repo: SumSubscriptions called", "user_id", userID, "service_name", serviceName)
+// PurgeDeletedSubscriptions removes the subscriptions of every organisation
+// deleted before cutoff, with their prices, tags, members and pauses, and
+// returns the number of subscriptions removed.
+func (sr *SubsRepo) PurgeDeletedSubscriptions(ctx context.Context, cutoff time.Time) (int64, error) {
+	logger.Log.Info("Repo: PurgeDeletedSubscriptions called", "cutoff", cutoff)
 
-	builder := sr.builder.Select("COALESCE(SUM(price), 0)").From("Subscriptions")
+	query, args, err := sr.builder.
+		Delete("Subscriptions").
+		Where("deleted_at < ?", cutoff).
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build purge query", "error", err)
+
+		return 0, fmt.Errorf("failed to build purge query: %w", err)
+	}
+
+	organizationIDs, err := organizationIDs(ctx, sr.db, sr.builder)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+
+	// Each organisation is purged in its own tenant transaction, so the
+	// row-level security policies hold whichever role the pool connects as.
+	for _, organizationID := range organizationIDs {
+		result, err := execTenant(tenant.WithOrganization(ctx, organizationID), sr.db, query, args...)
+		if err != nil {
+			logger.Log.Error("Repo: Failed to purge subscriptions", "organization_id", organizationID, "error", err)
+
+			return purged, fmt.Errorf("failed to purge subscriptions: %w", err)
+		}
 
-	if userID != "" {
-		builder = builder.Where("user_id = ?", userID)
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+			return purged, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+
+		purged += rowsAffected
 	}
 
-	if serviceName != "" {
-		builder = builder.Where("service_name = ?", serviceName)
+	logger.Log.Info("Repo: Deleted subscriptions purged", "cutoff", cutoff, "rows_affected", purged)
+
+	return purged, nil
+}
+
+func (sr *SubsRepo) ChangeSubscriptionStatus(ctx context.Context, change entities.StatusChange) error {
+	logger.Log.Info("Repo: ChangeSubscriptionStatus called", "id", change.SubscriptionID, "from", change.From, "to", change.To)
+
+	builder := sr.builder.
+		Update("Subscriptions").
+		Set("status", change.To).
+		Set("status_changed_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": change.SubscriptionID, "status": change.From, "deleted_at": nil})
+
+	if change.To == entities.StatusCancelled {
+		builder = builder.Set("cancelled_at", squirrel.Expr("now()"))
 	}
 
-	if startPeriod != nil {
-		builder = builder.Where("start_date >= ?", *startPeriod)
+	// Ending a trial early ends it today, so the months from now on are billed.
+	if change.From == entities.StatusTrial && change.To == entities.StatusActive {
+		builder = builder.Set("trial_end_date", change.Date)
 	}
 
-	if endPeriod != nil {
-		builder = builder.Where("end_date <= ?", *endPeriod)
+	if change.EndDate != nil {
+		builder = builder.Set("end_date", *change.EndDate)
 	}
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		logger.Log.Error("Repo: Failed to build sum query", "error", err)
+		logger.Log.Error("Repo: Failed to build status update query", "error", err)
 
-		return 0, fmt.Errorf("failed to build sum query: %w", err)
+		return fmt.Errorf("failed to build status update query: %w", err)
 	}
 
-	var sum int
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		logger.Log.Error("Failed to start transaction", "error", err)
 
-	err = sr.db.QueryRow(query, args...).Scan(&sum)
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Log.Error("Repo: Failed to rollback transaction", "error", rollbackErr)
+			}
+		}
+	}()
+
+	before, err := sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": change.SubscriptionID})
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute status update", "error", err)
+
+		return fmt.Errorf("failed to change subscription status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Log.Error("Repo: Failed to get affected rows", "error", err)
+
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		logger.Log.Error("Repo: Subscription status changed concurrently", "id", change.SubscriptionID, "from", change.From)
+
+		err = fmt.Errorf("%w: subscription is no longer %s", errormsgs.Conflict, change.From)
+
+		return err
+	}
+
+	var pauseQuery squirrel.Sqlizer
+
+	switch {
+	case change.To == entities.StatusPaused:
+		pauseQuery = sr.builder.
+			Insert("subscription_pauses").
+			Columns("subscription_id", "paused_from").
+			Values(change.SubscriptionID, change.Date)
+	// A paused subscription that is cancelled never resumes, so its pause
+	// stays open and the months up to the end date are not billed.
+	case change.From == entities.StatusPaused && change.To == entities.StatusActive:
+		pauseQuery = sr.builder.
+			Update("subscription_pauses").
+			Set("resumed_on", change.Date).
+			Where(squirrel.Eq{"subscription_id": change.SubscriptionID, "resumed_on": nil})
+	}
+
+	if pauseQuery != nil {
+		query, args, err = pauseQuery.ToSql()
+		if err != nil {
+			logger.Log.Error("Repo: Failed to build pause query", "error", err)
+
+			return fmt.Errorf("failed to build pause query: %w", err)
+		}
+
+		if _, err = tx.Exec(query, args...); err != nil {
+			logger.Log.Error("Repo: Failed to record pause", "error", err)
+
+			return fmt.Errorf("failed to record pause: %w", err)
+		}
+	}
+
+	var after []subscriptionSnapshot
+
+	after, err = sr.snapshotSubscriptions(tx, squirrel.Eq{"s.id": change.SubscriptionID})
 	if err != nil {
-		logger.Log.Error("Repo: Failed to execute sum query", "error", err)
+		return err
+	}
 
-		return 0, fmt.Errorf("failed to execute sum query: %w", err)
+	if err = sr.auditSubscriptions(ctx, tx, entities.AuditStatusChange, before, after); err != nil {
+		return err
 	}
 
-	logger.Log.Info("Repo: SumSubscriptions completed", "user_id", userID, "service_name", serviceName, "total", sum)
+	if err = tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return err
+	}
+
+	logger.Log.Info("Repo: Subscription status changed successfully", "id", change.SubscriptionID, "status", change.To)
 
-	return sum, nil
+	return nil
 }
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/domain/entities"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// subscriptionTagsSQL selects the sorted tag names of each Subscriptions row as JSON.
+const subscriptionTagsSQL = "to_json(ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id " +
+	"WHERE st.subscription_id = Subscriptions.id ORDER BY t.name))"
+
+// tagsMatch matches subscriptions carrying every one of the tags.
+func tagsMatch(tags []string) squirrel.Sqlizer {
+	return squirrel.Expr(
+		"id IN (SELECT st.subscription_id FROM subscription_tags st JOIN tags t ON t.id = st.tag_id "+
+			"WHERE t.name = ANY(?) GROUP BY st.subscription_id HAVING COUNT(*) = ?)",
+		tags, len(tags),
+	)
+}
+
+// setSubscriptionTags replaces the tags of the subscriptions, adding tag names
+// not seen before.
+func (sr *SubsRepo) setSubscriptionTags(tx *sql.Tx, subscriptionIDs []string, tags []string) error {
+	queries := []squirrel.Sqlizer{
+		sr.builder.
+			Delete("subscription_tags").
+			Where("subscription_id = ANY(?::uuid[])", subscriptionIDs),
+	}
+
+	if len(tags) > 0 {
+		queries = append(queries,
+			sr.builder.
+				Insert("tags").
+				Columns("name").
+				Select(sr.builder.Select().Column(squirrel.Expr("unnest(?::text[])", tags))).
+				Suffix("ON CONFLICT (organization_id, name) DO NOTHING"),
+			sr.builder.
+				Insert("subscription_tags").
+				Columns("subscription_id", "tag_id").
+				Select(sr.builder.
+					Select("s.id", "t.id").
+					From("unnest(?::uuid[]) AS s(id)").
+					CrossJoin("tags t").
+					Where("t.name = ANY(?)", tags)),
+		)
+	}
+
+	for _, q := range queries {
+		query, args, err := q.ToSql()
+		if err != nil {
+			logger.Log.Error("Repo: Failed to build tags query", "error", err)
+
+			return fmt.Errorf("failed to build tags query: %w", err)
+		}
+
+		if _, err := tx.Exec(query, args...); err != nil {
+			logger.Log.Error("Repo: Failed to set subscription tags", "error", err)
+
+			return fmt.Errorf("failed to set subscription tags: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetTags returns every tag with the number of live subscriptions carrying it.
+func (sr *SubsRepo) GetTags(ctx context.Context) ([]entities.Tag, error) {
+	logger.Log.Info("Repo: GetTags called")
+
+	query, args, err := sr.builder.
+		Select("t.name", "COUNT(s.id)").
+		From("tags t").
+		LeftJoin("subscription_tags st ON st.tag_id = t.id").
+		LeftJoin("Subscriptions s ON s.id = st.subscription_id AND s.deleted_at IS NULL").
+		GroupBy("t.name").
+		OrderBy("t.name").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build tags query", "error", err)
+
+		return nil, fmt.Errorf("failed to build tags query: %w", err)
+	}
+
+	tx, err := beginTenant(ctx, sr.db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute tags query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute tags query: %w", err)
+	}
+
+	defer rows.Close()
+
+	tags := make([]entities.Tag, 0)
+
+	for rows.Next() {
+		var tag entities.Tag
+		if err := rows.Scan(&tag.Name, &tag.Subscriptions); err != nil {
+			logger.Log.Error("Repo: Failed to scan tag row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	logger.Log.Info("Repo: Tags fetched successfully", "count", len(tags))
+
+	return tags, nil
+}
@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/agl/online_subs/internal/tenant"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// tenantRole is the role tenant transactions run as. The row-level security
+// policies of the tenant tables only expose rows of app.organization_id to it.
+const tenantRole = "subs_tenant"
+
+var errNoOrganization = errors.New("no organization in request context")
+
+// beginTenant starts a transaction scoped to the organisation of ctx. Rows
+// inserted inside it belong to that organisation.
+func beginTenant(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	organizationID := tenant.OrganizationID(ctx)
+	if organizationID == "" {
+		logger.Log.Error("Repo: Organization not resolved")
+
+		return nil, errNoOrganization
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Log.Error("Failed to start transaction", "error", err)
+
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.organization_id', $1, true)", organizationID); err != nil {
+		rollback(tx)
+		logger.Log.Error("Repo: Failed to set organization", "error", err)
+
+		return nil, fmt.Errorf("failed to set organization: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+tenantRole); err != nil {
+		rollback(tx)
+		logger.Log.Error("Repo: Failed to switch to tenant role", "error", err)
+
+		return nil, fmt.Errorf("failed to switch to tenant role: %w", err)
+	}
+
+	return tx, nil
+}
+
+// execTenant runs a single statement in its own tenant transaction.
+func execTenant(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	tx, err := beginTenant(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rollback(tx)
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Log.Error("Repo: Failed to commit transaction", "error", err)
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// organizationIDs lists every organisation, for jobs that run across tenants.
+func organizationIDs(ctx context.Context, db *sql.DB, builder squirrel.StatementBuilderType) ([]string, error) {
+	query, args, err := builder.
+		Select("id").
+		From("organizations").
+		OrderBy("id").
+		ToSql()
+
+	if err != nil {
+		logger.Log.Error("Repo: Failed to build organizations query", "error", err)
+
+		return nil, fmt.Errorf("failed to build organizations query: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Log.Error("Repo: Failed to execute organizations query", "error", err)
+
+		return nil, fmt.Errorf("failed to execute organizations query: %w", err)
+	}
+
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			logger.Log.Error("Repo: Failed to scan organization row", "error", err)
+
+			return nil, fmt.Errorf("failed to scan organization row: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("Repo: Error in rows iteration", "error", err)
+
+		return nil, fmt.Errorf("error in rows iteration: %w", err)
+	}
+
+	return ids, nil
+}
+
+// rollback ends a transaction that is not committed, e.g. one used only for reads.
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logger.Log.Error("Repo: Failed to rollback transaction", "error", err)
+	}
+}
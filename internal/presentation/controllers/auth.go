@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// withAuthentication resolves the principal of each request with the first
+// authenticator that recognises its credentials and checks that it holds
+// auth.ScopeRead for safe methods and auth.ScopeWrite for the rest.
+func withAuthentication(authenticators []auth.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticate(authenticators, r)
+		if err != nil {
+			if !errors.Is(err, auth.ErrNoCredentials) && !errors.Is(err, auth.ErrInvalidCredentials) {
+				logger.Log.Error("Failed to authenticate request", "error", err)
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+
+				return
+			}
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="online_subs"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		scope := auth.ScopeWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			scope = auth.ScopeRead
+		}
+
+		if !principal.HasScope(scope) {
+			logger.Log.Error("Missing scope", "subject", principal.Subject, "scope", scope)
+			writeForbidden(w, errormsgs.Deny("missing_scope_"+scope))
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func authenticate(authenticators []auth.Authenticator, r *http.Request) (auth.Principal, error) {
+	for _, authenticator := range authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) {
+			continue
+		}
+
+		return principal, err
+	}
+
+	return auth.Principal{}, auth.ErrNoCredentials
+}
+
+// writeForbidden answers a policy denial with 403 and its machine-readable
+// reason, e.g. {"error": "forbidden", "reason": "not_owner"}.
+func writeForbidden(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+
+	body := map[string]string{"error": "forbidden", "reason": errormsgs.DenialReason(err)}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Log.Error("Failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
+
+// requireIfMatch returns the If-Match header of a write, answering 428 when
+// it is missing so clients cannot overwrite changes they have not seen.
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(w, "If-Match header is required; send the ETag of the last read", http.StatusPreconditionRequired)
+
+		return "", false
+	}
+
+	return ifMatch, true
+}
+
+// writeETag sets the ETag header and answers 304 when the If-None-Match of
+// the request already lists it. It reports whether the response is done.
+func writeETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && dto.MatchETag(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+
+		return true
+	}
+
+	return false
+}
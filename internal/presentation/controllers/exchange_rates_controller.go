@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+type ExchangeRatesController struct {
+	service ports.ExchangeRateService
+}
+
+func NewExchangeRatesController(service ports.ExchangeRateService) *ExchangeRatesController {
+	return &ExchangeRatesController{
+		service: service,
+	}
+}
+
+func (ec *ExchangeRatesController) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /exchange-rates", ec.GetExchangeRates)
+	mux.HandleFunc("POST /exchange-rates", ec.SaveExchangeRates)
+	mux.HandleFunc("POST /exchange-rates/import", ec.ImportExchangeRatesCSV)
+}
+
+// @Summary Save exchange rates
+// @Description Create or replace exchange rates. A rate applies from its date until the next rate for the same pair.
+// @Description Rates belong to the organisation of the caller and only convert its own costs.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param rates body []dto.ExchangeRate true "Exchange rates"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /exchange-rates [post]
+func (ec *ExchangeRatesController) SaveExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var rates []dto.ExchangeRate
+	if err := json.NewDecoder(r.Body).Decode(&rates); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	count, err := ec.service.SaveExchangeRates(r.Context(), rates)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(map[string]any{"status": "saved", "count": count}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Import exchange rates from CSV
+// @Description Create or replace exchange rates from CSV rows of date,from,to,rate (date as YYYY-MM-DD, header optional).
+// @Tags exchange-rates
+// @Accept text/csv
+// @Produce json
+// @Param file body string true "CSV content"
+// @Success 201 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /exchange-rates/import [post]
+func (ec *ExchangeRatesController) ImportExchangeRatesCSV(w http.ResponseWriter, r *http.Request) {
+	count, err := ec.service.ImportExchangeRatesCSV(r.Context(), r.Body)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(map[string]any{"status": "saved", "count": count}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary List exchange rates
+// @Description Get stored exchange rates, optionally filtered by currency pair
+// @Tags exchange-rates
+// @Produce json
+// @Param from query string false "Source currency"
+// @Param to query string false "Target currency"
+// @Success 200 {array} dto.ExchangeRate
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /exchange-rates [get]
+func (ec *ExchangeRatesController) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := ec.service.GetExchangeRates(r.Context(), r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(rates); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
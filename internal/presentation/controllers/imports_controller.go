@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+const (
+	// maxImportBytes bounds the size of an uploaded CSV.
+	maxImportBytes = 64 << 20
+	// importMemoryBytes is how much of an upload is held in memory; the rest
+	// spills to temporary files.
+	importMemoryBytes = 8 << 20
+)
+
+type ImportsController struct {
+	service ports.ImportService
+}
+
+func NewImportsController(service ports.ImportService) *ImportsController {
+	return &ImportsController{
+		service: service,
+	}
+}
+
+func (ic *ImportsController) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /imports", ic.ImportSubscriptionsCSV)
+	mux.HandleFunc("GET /imports/{id}", ic.GetImportJob)
+}
+
+// @Summary Import subscriptions from CSV
+// @Description Upload a CSV of subscriptions with a header row. Every row is checked with the rules of POST /subscriptions
+// @Description and, unless dry_run is set, the valid ones are created together; invalid rows are listed with their line in the job report.
+// @Description The import runs in the background: poll GET /imports/{id} until its status is completed or failed.
+// @Description mapping is a JSON object naming the CSV column of each field, e.g. {"service_name":"Service","price":"Cost"};
+// @Description unmapped fields are read from the column named after the field. Tags are separated by commas or semicolons.
+// @Description Service names are looked up while validating; unknown names are added to the catalog in the transaction that creates the subscriptions.
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Param mapping formData string false "Field to CSV column mapping as a JSON object"
+// @Param date_format formData string false "Date format spelled with YYYY, MM and DD, e.g. DD.MM.YYYY (default YYYY-MM-DD or MM-YYYY)"
+// @Param currency formData string false "Currency of rows without one"
+// @Param dry_run formData bool false "Validate without creating subscriptions"
+// @Success 202 {object} dto.ImportJob
+// @Header 202 {string} Location "URL of the import job"
+// @Failure 400 {object} map[string]string "Invalid upload or options"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Invalid CSV or internal error"
+// @Router /imports [post]
+func (ic *ImportsController) ImportSubscriptionsCSV(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	if err := r.ParseMultipartForm(importMemoryBytes); err != nil {
+		http.Error(w, "Invalid multipart upload: "+err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "CSV file is required", http.StatusBadRequest)
+
+		return
+	}
+
+	defer file.Close()
+
+	options := dto.ImportOptions{
+		DateFormat: r.FormValue("date_format"),
+		Currency:   r.FormValue("currency"),
+	}
+
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			http.Error(w, "mapping must be a JSON object of field names to column names", http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if dryRun := r.FormValue("dry_run"); dryRun != "" {
+		options.DryRun, err = strconv.ParseBool(dryRun)
+		if err != nil {
+			http.Error(w, "dry_run must be true or false", http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	job, err := ic.service.ImportSubscriptionsCSV(r.Context(), options, file)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Location", "/imports/"+job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get import job
+// @Description Get the status of a CSV import with its counts and row-level error report.
+// @Description Users see the imports they started; admins see all of their organisation.
+// @Tags imports
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} dto.ImportJob
+// @Failure 404 {object} map[string]string "Import job not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /imports/{id} [get]
+func (ic *ImportsController) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := ic.service.GetImportJob(r.Context(), r.PathValue("id"))
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+)
+
+const mergePatchType = "application/merge-patch+json"
+
+// decodeMergePatch reads a JSON merge patch body, also accepting plain
+// application/json. It answers 415 or 400 itself and reports whether the
+// patch was read.
+func decodeMergePatch(w http.ResponseWriter, r *http.Request, patch *dto.SubscriptionPatch) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergePatchType && mediaType != "application/json") {
+			http.Error(w, "Content-Type must be "+mergePatchType, http.StatusUnsupportedMediaType)
+
+			return false
+		}
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(patch); err != nil {
+		http.Error(w, "Invalid merge patch: "+err.Error(), http.StatusBadRequest)
+
+		return false
+	}
+
+	return true
+}
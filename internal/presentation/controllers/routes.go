@@ -0,0 +1,9 @@
+package controllers
+
+import "net/http"
+
+// RouteRegistrar is implemented by controllers that mount their routes on the
+// server started by SubsController.
+type RouteRegistrar interface {
+	RegisterRoutes(mux *http.ServeMux)
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+type ServicesController struct {
+	service ports.ServiceCatalogService
+}
+
+func NewServicesController(service ports.ServiceCatalogService) *ServicesController {
+	return &ServicesController{
+		service: service,
+	}
+}
+
+func (svc *ServicesController) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /services", svc.CreateService)
+	mux.HandleFunc("GET /services", svc.GetServices)
+	mux.HandleFunc("GET /services/{id}", svc.GetServiceByID)
+	mux.HandleFunc("PUT /services/{id}", svc.UpdateService)
+	mux.HandleFunc("DELETE /services/{id}", svc.DeleteService)
+	mux.HandleFunc("POST /services/{id}/merge", svc.MergeServices)
+}
+
+// writeCatalogError maps catalog errors: 404 for unknown services, 409 for
+// duplicate names or aliases and services still in use, and 403 for policy
+// denials.
+func writeCatalogError(w http.ResponseWriter, err error) {
+	switch {
+	case errormsgs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errormsgs.IsConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errormsgs.IsForbidden(err):
+		writeForbidden(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Create service
+// @Description Add a service to the catalog. Aliases are matched case- and whitespace-insensitively
+// @Description when subscriptions are created with a free-text service name.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body dto.Service true "Service info"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 409 {object} map[string]string "Name or alias already in use"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services [post]
+func (svc *ServicesController) CreateService(w http.ResponseWriter, r *http.Request) {
+	var serviceDTO dto.Service
+	if err := json.NewDecoder(r.Body).Decode(&serviceDTO); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	id, err := svc.service.CreateService(r.Context(), serviceDTO)
+	if err != nil {
+		writeCatalogError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "created", "id": id}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary List services
+// @Description Get the service catalog, optionally filtered by category
+// @Tags services
+// @Produce json
+// @Param category query string false "Category"
+// @Success 200 {array} dto.Service
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services [get]
+func (svc *ServicesController) GetServices(w http.ResponseWriter, r *http.Request) {
+	services, err := svc.service.GetServices(r.Context(), r.URL.Query().Get("category"))
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(services); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get service by ID
+// @Description Get a single catalog entry by its ID
+// @Tags services
+// @Produce json
+// @Param id path string true "Service ID"
+// @Success 200 {object} dto.Service
+// @Failure 404 {object} map[string]string "Service not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services/{id} [get]
+func (svc *ServicesController) GetServiceByID(w http.ResponseWriter, r *http.Request) {
+	service, err := svc.service.GetServiceByID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeCatalogError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(service); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Update service
+// @Description Replace a catalog entry. Subscriptions of the service are renamed to the new canonical name.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "Service ID"
+// @Param service body dto.Service true "Service info"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Service not found"
+// @Failure 409 {object} map[string]string "Name or alias already in use"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services/{id} [put]
+func (svc *ServicesController) UpdateService(w http.ResponseWriter, r *http.Request) {
+	var serviceDTO dto.Service
+	if err := json.NewDecoder(r.Body).Decode(&serviceDTO); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := svc.service.UpdateService(r.Context(), serviceDTO, r.PathValue("id")); err != nil {
+		writeCatalogError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "updated"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Delete service
+// @Description Remove a catalog entry that no subscription refers to
+// @Tags services
+// @Produce json
+// @Param id path string true "Service ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string "Service not found"
+// @Failure 409 {object} map[string]string "Service still in use"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services/{id} [delete]
+func (svc *ServicesController) DeleteService(w http.ResponseWriter, r *http.Request) {
+	if err := svc.service.DeleteService(r.Context(), r.PathValue("id")); err != nil {
+		writeCatalogError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "deleted"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Merge services
+// @Description Move the subscriptions and aliases of source_id into this service and delete the source,
+// @Description e.g. to fold an automatically added "Яндекс Плюс" into "Yandex Plus".
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "Target service ID"
+// @Param merge body dto.MergeServicesRequest true "Source service"
+// @Success 200 {object} dto.Service
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Service not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services/{id}/merge [post]
+func (svc *ServicesController) MergeServices(w http.ResponseWriter, r *http.Request) {
+	var req dto.MergeServicesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	service, err := svc.service.MergeServices(r.Context(), r.PathValue("id"), req.SourceID)
+	if err != nil {
+		writeCatalogError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(service); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+// batchItemStatus is the status the single-subscription endpoint would answer
+// the operation with, or 424 for one left unwritten by an atomic batch.
+func batchItemStatus(item dto.BatchItemResult) int {
+	switch {
+	case item.Err == nil && item.Op == dto.BatchCreate:
+		return http.StatusCreated
+	case item.Err == nil:
+		return http.StatusOK
+	case errormsgs.IsNotApplied(item.Err):
+		return http.StatusFailedDependency
+	case errormsgs.IsNotFound(item.Err):
+		return http.StatusNotFound
+	case errormsgs.IsPreconditionFailed(item.Err):
+		return http.StatusPreconditionFailed
+	case errormsgs.IsPreconditionRequired(item.Err):
+		return http.StatusPreconditionRequired
+	case errormsgs.IsConflict(item.Err):
+		return http.StatusConflict
+	case errormsgs.IsForbidden(item.Err):
+		return http.StatusForbidden
+	case errormsgs.IsInvalidData(item.Err):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// @Summary Batch subscription writes
+// @Description Create, replace (update), merge-patch (patch) or delete up to 1000 subscriptions in one request.
+// @Description In atomic mode (default) every operation is written in one transaction or none is, and the response is 422 when any fails;
+// @Description in best_effort mode each valid operation is written on its own. Creations are written with multi-row inserts.
+// @Description Every item reports the status the single-subscription endpoint would answer with; 400 marks invalid operations
+// @Description and 424 operations left unwritten by a failed atomic batch.
+// @Description update, patch and delete require if_match, the ETag of the subscription, or report 428; a subscription can appear in only one operation.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body dto.BatchRequest true "Operations"
+// @Success 200 {object} dto.BatchResponse
+// @Failure 400 {object} map[string]string "Invalid request body, mode or number of operations"
+// @Failure 422 {object} dto.BatchResponse "Atomic batch not written"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/batch [post]
+func (sc *SubsController) BatchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req dto.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	resp, err := sc.service.BatchSubscriptions(r.Context(), req)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if errormsgs.IsInvalidData(err) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	for i, item := range resp.Items {
+		resp.Items[i].Status = batchItemStatus(item)
+
+		if item.Err != nil {
+			resp.Items[i].Error = item.Err.Error()
+			resp.Items[i].Reason = errormsgs.DenialReason(item.Err)
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Mode == dto.BatchAtomic && resp.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -4,27 +4,34 @@ import (
 	"encoding/json"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/agl/online_subs/internal/application/dto"
 	"github.com/agl/online_subs/internal/application/ports"
+	"github.com/agl/online_subs/internal/auth"
 	"github.com/agl/online_subs/internal/errormsgs"
 	"github.com/agl/online_subs/pkg/logger"
 )
 
 type SubsController struct {
-	service ports.SubscriptionService
-	port    string
+	service        ports.SubscriptionService
+	authenticators []auth.Authenticator
+	registrars     []RouteRegistrar
+	port           string
 }
 
-func NewSubsController(service ports.SubscriptionService) *SubsController {
+// NewSubsController serves requests authenticated by any of authenticators.
+func NewSubsController(service ports.SubscriptionService, authenticators []auth.Authenticator, registrars ...RouteRegistrar) *SubsController {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
 	return &SubsController{
-		service: service,
-		port:    port,
+		service:        service,
+		authenticators: authenticators,
+		registrars:     registrars,
+		port:           port,
 	}
 }
 
@@ -37,25 +44,55 @@ func (sc *SubsController) StartServer() {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("POST /subscriptions", sc.CreateSubscription)
-	mux.HandleFunc("GET /subscriptions/{userUUID}", sc.GetSubscriptionByUserUUID)
+	mux.HandleFunc("GET /subscriptions", sc.SearchSubscriptions)
+	mux.HandleFunc("GET /subscriptions/{id}", sc.GetSubscriptionByID)
+	mux.HandleFunc("PUT /subscriptions/{id}", sc.UpdateSubscriptionByID)
+	mux.HandleFunc("PATCH /subscriptions/{id}", sc.PatchSubscriptionByID)
+	mux.HandleFunc("DELETE /subscriptions/{id}", sc.DeleteSubscriptionByID)
+	mux.HandleFunc("GET /subscriptions/{id}/prices", sc.GetPriceHistory)
+	mux.HandleFunc("GET /subscriptions/{id}/history", sc.GetSubscriptionHistory)
+	mux.HandleFunc("GET /subscriptions/{id}/members", sc.GetSubscriptionMembers)
+	mux.HandleFunc("POST /subscriptions/{id}/members", sc.AddSubscriptionMember)
+	mux.HandleFunc("DELETE /subscriptions/{id}/members/{userUUID}", sc.RemoveSubscriptionMember)
+	mux.HandleFunc("POST /subscriptions/{id}/pause", sc.PauseSubscription)
+	mux.HandleFunc("POST /subscriptions/{id}/resume", sc.ResumeSubscription)
+	mux.HandleFunc("POST /subscriptions/{id}/cancel", sc.CancelSubscription)
+	mux.HandleFunc("POST /subscriptions/{id}/restore", sc.RestoreSubscription)
 	mux.HandleFunc("POST /subscriptions/filter", sc.GetSubscriptionFiltered)
-	mux.HandleFunc("PUT /subscriptions/{userUUID}", sc.UpdateSubscriptionByUserUUID)
-	mux.HandleFunc("DELETE /subscriptions/{userUUID}", sc.DeleteSubscriptionByUserUUID)
+	mux.HandleFunc("POST /subscriptions/batch", sc.BatchSubscriptions)
 	mux.HandleFunc("POST /subscriptions/sum", sc.SumSubscriptions)
+	mux.HandleFunc("GET /services/suggest", sc.SuggestServiceNames)
+	mux.HandleFunc("GET /tags", sc.GetTags)
+	mux.HandleFunc("GET /analytics/monthly", sc.MonthlySubscriptionCosts)
+	mux.HandleFunc("POST /analytics/aggregate", sc.AggregateSubscriptions)
+	mux.HandleFunc("GET /exports/subscriptions", sc.ExportSubscriptions)
+
+	mux.HandleFunc("GET /users/{userUUID}/subscriptions", sc.GetSubscriptionsByUserUUID)
+	mux.HandleFunc("PATCH /users/{userUUID}/subscriptions", sc.PatchSubscriptionsByUserUUID)
+	mux.HandleFunc("PUT /users/{userUUID}/subscriptions", sc.ReplaceSubscriptionsByUserUUID)
+	mux.HandleFunc("DELETE /users/{userUUID}/subscriptions", sc.DeleteSubscriptionByUserUUID)
+
+	for _, registrar := range sc.registrars {
+		registrar.RegisterRoutes(mux)
+	}
 
-	if err := http.ListenAndServe(":"+sc.port, mux); err != nil {
+	if err := http.ListenAndServe(":"+sc.port, withRequestID(withAuthentication(sc.authenticators, withOrganization(mux)))); err != nil {
 		logger.Log.Error("Failed to start server", "error", err)
 	}
 }
 
 // @Summary Create subscription
-// @Description Create a new subscription
+// @Description Create a new subscription. Dates are accepted as YYYY-MM-DD or legacy MM-YYYY.
+// @Description A trial_end_date in the future starts the subscription in the trial status.
+// @Description service_name is resolved through the service catalog aliases (or pass service_id); unknown names are added to the catalog.
+// @Description Tags are lower-cased; new tag names are created on first use.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body dto.Subscription true "Subscription info"
 // @Success 201 {object} map[string]string
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Router /subscriptions [post]
 func (sc *SubsController) CreateSubscription(w http.ResponseWriter, r *http.Request) {
@@ -66,7 +103,14 @@ func (sc *SubsController) CreateSubscription(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := sc.service.CreateSubscription(subDto); err != nil {
+	id, err := sc.service.CreateSubscription(r.Context(), subDto)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 
 		return
@@ -74,19 +118,26 @@ func (sc *SubsController) CreateSubscription(w http.ResponseWriter, r *http.Requ
 
 	w.WriteHeader(http.StatusCreated)
 
-	if err := json.NewEncoder(w).Encode(map[string]string{"status": "created"}); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "created", "id": id}); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
 // @Summary Get sum of subscriptions
-// @Description Get total price of subscriptions for user/service/period
+// @Description Get prorated cost of subscriptions for user/service/period, with prices normalised to a monthly cost by billing period.
+// @Description Open-ended subscriptions are counted up to the end of the period (current month if omitted).
+// @Description Costs are converted to target_currency (RUB if omitted) with the exchange rate in effect for each month.
+// @Description Months spent in a trial or paused at month end are not billed.
+// @Description Each month is billed at the price in effect for that month.
+// @Description Shared subscriptions count only the share of user_id; without user_id they count in full.
+// @Description group_by (service_name, user_id, month, tag) adds subtotals; a subscription with several tags counts towards each of them.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param filter body dto.SumSubscriptionsRequest true "Filter parameters"
 // @Success 200 {object} dto.SumSubscriptionsResponse
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Router /subscriptions/sum [post]
 func (sc *SubsController) SumSubscriptions(w http.ResponseWriter, r *http.Request) {
@@ -96,14 +147,18 @@ func (sc *SubsController) SumSubscriptions(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	total, err := sc.service.SumSubscriptions(req)
+	resp, err := sc.service.SumSubscriptions(r.Context(), req)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	resp := dto.SumSubscriptionsResponse{Total: total}
-
 	w.Header().Set("Content-Type", "application/json")
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -111,27 +166,222 @@ func (sc *SubsController) SumSubscriptions(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// @Summary Get subscription by user UUID
-// @Description Get subscription for a user by UUID
+// @Summary Get monthly cost time series
+// @Description Get total price and number of active subscriptions per month for user/service/period.
+// @Description The period defaults to the last 12 months ending with the current month.
+// @Description With user_id, shared subscriptions count only that user's share.
+// @Tags analytics
+// @Produce json
+// @Param user_id query string false "User UUID"
+// @Param service_name query string false "Service name"
+// @Param start_date query string false "Start month (MM-YYYY)"
+// @Param end_date query string false "End month (MM-YYYY)"
+// @Param target_currency query string false "ISO 4217 currency to convert costs to (default RUB)"
+// @Success 200 {array} dto.MonthlyCost
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /analytics/monthly [get]
+func (sc *SubsController) MonthlySubscriptionCosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	req := dto.SumSubscriptionsRequest{
+		UserID:         query.Get("user_id"),
+		ServiceName:    query.Get("service_name"),
+		StartPeriod:    query.Get("start_date"),
+		EndPeriod:      query.Get("end_date"),
+		TargetCurrency: query.Get("target_currency"),
+	}
+
+	costs, err := sc.service.MonthlySubscriptionCosts(r.Context(), req)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(costs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get grouped spend breakdown
+// @Description Get total cost, number of subscriptions and average cost grouped by service_name, user_id, month and/or tag.
+// @Description Results are sorted by sort_by (total, count, average) and limited server-side.
+// @Description Shared subscriptions are split between their owner and members when grouped by or filtered on user_id.
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param request body dto.AggregateSubscriptionsRequest true "Aggregation parameters"
+// @Success 200 {array} dto.AggregateSubscriptionsRow
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /analytics/aggregate [post]
+func (sc *SubsController) AggregateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req dto.AggregateSubscriptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := sc.service.AggregateSubscriptions(r.Context(), req)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(rows); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get subscription by ID
+// @Description Get a single subscription by its ID. Deleted subscriptions are not found unless an admin passes include_deleted.
 // @Tags subscriptions
 // @Produce json
-// @Param userUUID path string true "User UUID"
+// @Param id path string true "Subscription ID"
+// @Param include_deleted query bool false "Also return a deleted subscription (admins only)"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} dto.Subscription
+// @Header 200 {string} ETag "Version, status and next renewal of the subscription"
+// @Success 304 "Subscription not modified"
 // @Failure 400 {object} map[string]string "Bad request"
 // @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
-// @Router /subscriptions/{userUUID} [get]
-func (sc *SubsController) GetSubscriptionByUserUUID(w http.ResponseWriter, r *http.Request) {
+// @Router /subscriptions/{id} [get]
+func (sc *SubsController) GetSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Subscription ID is required", http.StatusBadRequest)
+		return
+	}
+
+	includeDeleted := false
+
+	if value := r.URL.Query().Get("include_deleted"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "include_deleted must be true or false", http.StatusBadRequest)
+
+			return
+		}
+
+		includeDeleted = parsed
+	}
+
+	sub, err := sc.service.GetSubscriptionByID(r.Context(), id, includeDeleted)
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if writeETag(w, r, dto.SubscriptionETag(sub)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Get subscription price history
+// @Description Get the prices of a subscription with the month each price took effect, oldest first
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} dto.PriceChange
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/prices [get]
+func (sc *SubsController) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	changes, err := sc.service.GetPriceHistory(r.Context(), r.PathValue("id"))
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary List subscriptions by user UUID
+// @Description Get all subscriptions of a user
+// @Tags subscriptions
+// @Produce json
+// @Param userUUID path string true "User UUID"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} dto.Subscription
+// @Header 200 {string} ETag "Versions of the listed subscriptions"
+// @Success 304 "Subscriptions not modified"
+// @Failure 400 {object} map[string]string "Bad request"
+// @Failure 404 {object} map[string]string "Subscriptions not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /users/{userUUID}/subscriptions [get]
+func (sc *SubsController) GetSubscriptionsByUserUUID(w http.ResponseWriter, r *http.Request) {
 	userUUID := r.PathValue("userUUID")
 	if userUUID == "" {
 		http.Error(w, "User UUID is required", http.StatusBadRequest)
 		return
 	}
 
-	sub, err := sc.service.GetSubscriptionByUserUUID(userUUID)
+	subscriptions, err := sc.service.GetSubscriptionsByUserUUID(r.Context(), userUUID)
 	if errormsgs.IsNotFound(err) {
 		http.Error(w, err.Error(), http.StatusNotFound)
-		
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
 		return
 	}
 
@@ -141,38 +391,54 @@ func (sc *SubsController) GetSubscriptionByUserUUID(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if writeETag(w, r, dto.CollectionETag(subscriptions)) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(sub); err != nil {
+	if err := json.NewEncoder(w).Encode(subscriptions); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
 // @Summary List subscriptions by filter
-// @Description Get subscriptions matching filter criteria
+// @Description Get subscriptions matching filter criteria. Price is compared as a minimum raw price; monthly_cost_min compares the cost normalised to a month.
+// @Description Results are paginated by limit (default 100, max 1000) and ordered by sort (start_date if omitted) and id.
+// @Description Pass next_cursor from the response as cursor, with the same sort and order, to fetch the next page.
+// @Description q fuzzy-matches service names and orders results by relevance unless sort is given.
+// @Description tags matches subscriptions carrying all of the given tags.
+// @Description Deleted subscriptions are skipped unless an admin sets include_deleted.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param filter body dto.Subscription true "Filter parameters"
-// @Success 200 {array} dto.Subscription
+// @Param filter body dto.FilterSubscriptionsRequest true "Filter and pagination parameters"
+// @Success 200 {object} dto.SubscriptionPage
 // @Failure 400 {object} map[string]string "Invalid request body"
 // @Failure 404 {object} map[string]string "No subscriptions found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Router /subscriptions/filter [post]
 func (sc *SubsController) GetSubscriptionFiltered(w http.ResponseWriter, r *http.Request) {
-	var subDto dto.Subscription
-	if err := json.NewDecoder(r.Body).Decode(&subDto); err != nil {
+	var req dto.FilterSubscriptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 
 		return
 	}
 
-	subscriptions, err := sc.service.GetSubscriptionFiltered(subDto)
+	page, err := sc.service.GetSubscriptionFiltered(r.Context(), req)
 	if errormsgs.IsNotFound(err) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 
 		return
 	}
 
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 
@@ -181,39 +447,338 @@ func (sc *SubsController) GetSubscriptionFiltered(w http.ResponseWriter, r *http
 
 	w.Header().Set("Content-Type", "application/json")
 
-	if err := json.NewEncoder(w).Encode(subscriptions); err != nil {
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+}
+
+// @Summary Search subscriptions
+// @Description Search subscriptions with query operators. Results are paginated like POST /subscriptions/filter.
+// @Description started_after and ended_before are exclusive; active_on matches subscriptions running on that day.
+// @Description Deleted subscriptions are skipped unless an admin passes include_deleted.
+// @Tags subscriptions
+// @Produce json
+// @Param q query string false "Fuzzy service name search; results default to best matches first"
+// @Param user_id query string false "User UUID"
+// @Param service_name_in query []string false "Service names, repeated or comma-separated" collectionFormat(csv)
+// @Param tags query []string false "Tags the subscription must all carry, repeated or comma-separated" collectionFormat(csv)
+// @Param currency query string false "ISO 4217 currency"
+// @Param billing_period query string false "Billing period" Enums(week, month, quarter, year)
+// @Param price_min query int false "Minimum price"
+// @Param price_max query int false "Maximum price"
+// @Param active_on query string false "Day the subscription is active on (YYYY-MM-DD)"
+// @Param started_after query string false "Started after this day (YYYY-MM-DD)"
+// @Param ended_before query string false "Ended before this day (YYYY-MM-DD)"
+// @Param limit query int false "Page size (default 100, max 1000)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field" Enums(price, start_date, service_name, relevance)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param include_total query bool false "Include the total number of matches"
+// @Param include_deleted query bool false "Also return deleted subscriptions (admins only)"
+// @Success 200 {object} dto.SubscriptionPage
+// @Failure 400 {object} map[string]string "Invalid query"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions [get]
+func (sc *SubsController) SearchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query, err := dto.ParseSubscriptionQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	page, err := sc.service.SearchSubscriptions(r.Context(), query)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Suggest service names
+// @Description Autocomplete service names starting with prefix, most subscribed first.
+// @Description Cyrillic prefixes are also matched by their Latin transliteration.
+// @Tags services
+// @Produce json
+// @Param prefix query string true "Service name prefix"
+// @Param limit query int false "Maximum number of suggestions (default 10, max 50)"
+// @Success 200 {array} dto.ServiceSuggestion
+// @Failure 400 {object} map[string]string "Invalid query"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /services/suggest [get]
+func (sc *SubsController) SuggestServiceNames(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "limit must be an integer", http.StatusBadRequest)
+
+			return
+		}
+
+		limit = parsed
+	}
+
+	suggestions, err := sc.service.SuggestServiceNames(r.Context(), r.URL.Query().Get("prefix"), limit)
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
-// @Summary Update subscription by user UUID
-// @Description Update an existing subscription by user UUID
+// @Summary List tags
+// @Description Get every tag with the number of subscriptions carrying it
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {array} dto.Tag
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /tags [get]
+func (sc *SubsController) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := sc.service.GetTags(r.Context())
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(tags); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Replace subscription by ID
+// @Description Replace an existing subscription by its ID with the full state in the body.
+// @Description Omitted fields take their defaults: no end_date makes it open-ended, price 0 makes it free and no tags removes them.
+// @Description A changed price is recorded in the price history from price_effective_date (current month if omitted) instead of replacing past prices.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string true "ETag of GET /subscriptions/{id}"
+// @Param subscription body dto.UpdateSubscription true "Full subscription state"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id} [put]
+func (sc *SubsController) UpdateSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Subscription ID is required", http.StatusBadRequest)
+		return
+	}
+
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var subDto dto.UpdateSubscription
+	if err := json.NewDecoder(r.Body).Decode(&subDto); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := sc.service.UpdateSubscriptionByID(r.Context(), subDto, id, ifMatch)
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if errormsgs.IsPreconditionFailed(err) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "updated"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Patch subscription by ID
+// @Description Apply a JSON merge patch (RFC 7396) to a subscription: absent fields are left unchanged
+// @Description and null clears a field, e.g. end_date: null makes the subscription open-ended again.
+// @Description A changed price is recorded in the price history from price_effective_date (current month if omitted).
+// @Tags subscriptions
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string true "ETag of GET /subscriptions/{id}"
+// @Param patch body dto.SubscriptionPatch true "Merge patch"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid merge patch"
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 415 {object} map[string]string "Not a merge patch"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id} [patch]
+func (sc *SubsController) PatchSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var patch dto.SubscriptionPatch
+	if !decodeMergePatch(w, r, &patch) {
+		return
+	}
+
+	err := sc.service.PatchSubscriptionByID(r.Context(), patch, r.PathValue("id"), ifMatch)
+
+	writePatchResult(w, err)
+}
+
+// @Summary Patch subscriptions by user UUID
+// @Description Apply a JSON merge patch (RFC 7396) to every subscription of a user at once.
+// @Description Absent fields are left unchanged and null clears a field; split_rule can only be changed per subscription.
+// @Tags subscriptions
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
 // @Param userUUID path string true "User UUID"
-// @Param subscription body dto.UpdateSubscription true "Update data"
+// @Param If-Match header string true "ETag of GET /users/{userUUID}/subscriptions"
+// @Param patch body dto.SubscriptionPatch true "Merge patch"
+// @Success 201 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid merge patch"
+// @Failure 404 {object} map[string]string "Subscriptions not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 415 {object} map[string]string "Not a merge patch"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /users/{userUUID}/subscriptions [patch]
+func (sc *SubsController) PatchSubscriptionsByUserUUID(w http.ResponseWriter, r *http.Request) {
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var patch dto.SubscriptionPatch
+	if !decodeMergePatch(w, r, &patch) {
+		return
+	}
+
+	err := sc.service.PatchSubscriptionsByUserUUID(r.Context(), patch, r.PathValue("userUUID"), ifMatch)
+
+	writePatchResult(w, err)
+}
+
+// @Summary Replace subscriptions by user UUID
+// @Description Replace every subscription of a user at once with the full state in the body.
+// @Description Omitted fields take their defaults like PUT /subscriptions/{id}; split_rule is kept per subscription.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param userUUID path string true "User UUID"
+// @Param If-Match header string true "ETag of GET /users/{userUUID}/subscriptions"
+// @Param subscription body dto.UpdateSubscription true "Full subscription state"
 // @Success 201 {object} map[string]string
 // @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Subscriptions not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
-// @Router /subscriptions/{userUUID} [put]
-func (sc *SubsController) UpdateSubscriptionByUserUUID(w http.ResponseWriter, r *http.Request) {
-	userUUID := r.PathValue("userUUID")
-	if userUUID == "" {
-		http.Error(w, "User UUID is required", http.StatusBadRequest)
+// @Router /users/{userUUID}/subscriptions [put]
+func (sc *SubsController) ReplaceSubscriptionsByUserUUID(w http.ResponseWriter, r *http.Request) {
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
 		return
 	}
 
 	var subDto dto.UpdateSubscription
 	if err := json.NewDecoder(r.Body).Decode(&subDto); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	err := sc.service.ReplaceSubscriptionsByUserUUID(r.Context(), subDto, r.PathValue("userUUID"), ifMatch)
+
+	writePatchResult(w, err)
+}
+
+func writePatchResult(w http.ResponseWriter, err error) {
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsPreconditionFailed(err) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
 		return
 	}
 
-	if err := sc.service.UpdateSubscriptionByUserUUID(subDto, userUUID); err != nil {
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+
 		return
 	}
 
@@ -224,15 +789,75 @@ func (sc *SubsController) UpdateSubscriptionByUserUUID(w http.ResponseWriter, r
 	}
 }
 
-// @Summary Delete subscription by user UUID
-// @Description Delete an existing subscription by user UUID
+// @Summary Delete subscription by ID
+// @Description Delete an existing subscription by its ID. It can be restored until the retention purge removes it.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string true "ETag of GET /subscriptions/{id}"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Invalid ID"
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id} [delete]
+func (sc *SubsController) DeleteSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Subscription ID is required", http.StatusBadRequest)
+		return
+	}
+
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := sc.service.DeleteSubscriptionByID(r.Context(), id, ifMatch)
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if errormsgs.IsPreconditionFailed(err) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "deleted"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Delete subscriptions by user UUID
+// @Description Delete all subscriptions of a user. They can be restored until the retention purge removes them.
 // @Tags subscriptions
 // @Produce json
 // @Param userUUID path string true "User UUID"
+// @Param If-Match header string true "ETag of GET /users/{userUUID}/subscriptions"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string "Invalid UUID"
+// @Failure 404 {object} map[string]string "Subscriptions not found"
+// @Failure 412 {object} map[string]string "Changed since the If-Match ETag was read"
+// @Failure 428 {object} map[string]string "If-Match header missing"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
 // @Failure 500 {object} map[string]string "Internal error"
-// @Router /subscriptions/{userUUID} [delete]
+// @Router /users/{userUUID}/subscriptions [delete]
 func (sc *SubsController) DeleteSubscriptionByUserUUID(w http.ResponseWriter, r *http.Request) {
 	userUUID := r.PathValue("userUUID")
 	if userUUID == "" {
@@ -240,7 +865,30 @@ func (sc *SubsController) DeleteSubscriptionByUserUUID(w http.ResponseWriter, r
 		return
 	}
 
-	if err := sc.service.DeleteSubscriptionByUserUUID(userUUID); err != nil {
+	ifMatch, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := sc.service.DeleteSubscriptionByUserUUID(r.Context(), userUUID, ifMatch)
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if errormsgs.IsPreconditionFailed(err) {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
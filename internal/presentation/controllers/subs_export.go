@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/pkg/logger"
+	"github.com/agl/online_subs/pkg/xlsx"
+)
+
+// exportColumns head the CSV and XLSX exports, in the order of exportCells.
+var exportColumns = []string{
+	"id", "service_id", "service_name", "price", "currency", "billing_period", "billing_interval", "monthly_cost",
+	"user_id", "start_date", "end_date", "status", "trial_end_date", "split_rule", "tags", "version", "deleted_at",
+}
+
+// exportCells are the columns of sub; numbers stay numbers for XLSX.
+func exportCells(sub dto.Subscription) []any {
+	return []any{
+		sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingInterval, sub.MonthlyCost,
+		sub.UserID, sub.StartDate, sub.EndDate, sub.Status, sub.TrialEndDate, sub.SplitRule, strings.Join(sub.Tags, ";"), sub.Version, sub.DeletedAt,
+	}
+}
+
+// subscriptionEncoder writes exported subscriptions in one format.
+type subscriptionEncoder interface {
+	Encode(sub dto.Subscription) error
+	Close() error
+}
+
+type exportFormat struct {
+	contentType string
+	newEncoder  func(w io.Writer) (subscriptionEncoder, error)
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv":    {"text/csv; charset=utf-8", newCSVEncoder},
+	"ndjson": {"application/x-ndjson", newNDJSONEncoder},
+	"xlsx":   {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", newXLSXEncoder},
+}
+
+type csvEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) (subscriptionEncoder, error) {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+
+	return &csvEncoder{writer: writer}, nil
+}
+
+func (e *csvEncoder) Encode(sub dto.Subscription) error {
+	cells := exportCells(sub)
+	record := make([]string, len(cells))
+
+	for i, cell := range cells {
+		switch v := cell.(type) {
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			record[i] = csvText(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	return e.writer.Write(record)
+}
+
+// csvText keeps spreadsheets from reading text as a formula by prefixing
+// text that would start one with a quote. XLSX cells are typed, so only CSV
+// needs this.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
+
+func (e *csvEncoder) Close() error {
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
+
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) (subscriptionEncoder, error) {
+	return &ndjsonEncoder{encoder: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonEncoder) Encode(sub dto.Subscription) error {
+	return e.encoder.Encode(sub)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+type xlsxEncoder struct {
+	writer *xlsx.Writer
+}
+
+func newXLSXEncoder(w io.Writer) (subscriptionEncoder, error) {
+	writer, err := xlsx.NewWriter(w, "Subscriptions")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]any, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return nil, err
+	}
+
+	return &xlsxEncoder{writer: writer}, nil
+}
+
+func (e *xlsxEncoder) Encode(sub dto.Subscription) error {
+	return e.writer.WriteRow(exportCells(sub))
+}
+
+func (e *xlsxEncoder) Close() error {
+	return e.writer.Close()
+}
+
+// @Summary Export subscriptions
+// @Description Download every subscription matching the filters of GET /subscriptions as CSV (default), NDJSON or XLSX.
+// @Description Rows are streamed by start date straight from the database, so exports of any size use constant memory.
+// @Description There are no pages: limit, cursor, sort, order and include_total are rejected.
+// @Description An error after the first row ends the download early, leaving a truncated file.
+// @Description CSV text starting with =, +, -, @, a tab or a carriage return is prefixed with ' so that spreadsheets do not run it as a formula.
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, ndjson, xlsx)
+// @Param user_id query string false "Owner UUID (users always get their own)"
+// @Param service_name_in query string false "Service names or aliases, comma-separated or repeated"
+// @Param tags query string false "Tags every result carries, comma-separated or repeated"
+// @Param q query string false "Full-text search on the service name"
+// @Param currency query string false "Currency"
+// @Param billing_period query string false "Billing period" Enums(week, month, quarter, year)
+// @Param price_min query int false "Minimum price"
+// @Param price_max query int false "Maximum price"
+// @Param active_on query string false "Day the subscription is active on (YYYY-MM-DD)"
+// @Param started_after query string false "Started after this day (YYYY-MM-DD)"
+// @Param ended_before query string false "Ended before this day (YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also export deleted subscriptions (admins only)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string "Invalid query or format"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /exports/subscriptions [get]
+func (sc *SubsController) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	name := values.Get("format")
+	if name == "" {
+		name = "csv"
+	}
+
+	format, ok := exportFormats[name]
+	if !ok {
+		http.Error(w, "format must be csv, ndjson or xlsx", http.StatusBadRequest)
+
+		return
+	}
+
+	values.Del("format")
+
+	for _, key := range []string{"limit", "cursor", "sort", "order", "include_total"} {
+		if values.Has(key) {
+			http.Error(w, key+" does not apply to exports", http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	query, err := dto.ParseSubscriptionQuery(values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	// The response starts with the first row, so errors found before it still
+	// get a proper status.
+	var encoder subscriptionEncoder
+
+	start := func() error {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+name+`"`)
+
+		started, err := format.newEncoder(w)
+		encoder = started
+
+		return err
+	}
+
+	err = sc.service.ExportSubscriptions(r.Context(), query, func(sub dto.Subscription) error {
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		return encoder.Encode(sub)
+	})
+
+	if err != nil && encoder == nil {
+		if errormsgs.IsForbidden(err) {
+			writeForbidden(w, err)
+
+			return
+		}
+
+		if errormsgs.IsInvalidData(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	if err != nil {
+		logger.Log.Error("Export ended early", "format", name, "error", err)
+
+		return
+	}
+
+	if encoder == nil {
+		if err := start(); err != nil {
+			logger.Log.Error("Failed to start export", "format", name, "error", err)
+
+			return
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		logger.Log.Error("Failed to finish export", "format", name, "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+// @Summary Get subscription history
+// @Description Get the audit log of a subscription, oldest first: who created, updated, changed the status of or deleted it,
+// @Description with the subscription before and after each change and the X-Request-ID of the request.
+// @Description The log is kept after the subscription is deleted.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} dto.AuditEntry
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/history [get]
+func (sc *SubsController) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	entries, err := sc.service.GetSubscriptionHistory(r.Context(), r.PathValue("id"))
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+// @Summary Pause subscription
+// @Description Pause an active subscription. Months paused at month end are not billed.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} dto.Subscription
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/pause [post]
+func (sc *SubsController) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := sc.service.PauseSubscription(r.Context(), r.PathValue("id"))
+
+	writeStatusChange(w, sub, err)
+}
+
+// @Summary Resume subscription
+// @Description Resume a paused subscription.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} dto.Subscription
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/resume [post]
+func (sc *SubsController) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := sc.service.ResumeSubscription(r.Context(), r.PathValue("id"))
+
+	writeStatusChange(w, sub, err)
+}
+
+// @Summary Cancel subscription
+// @Description Cancel a trial, active or paused subscription. By default the subscription ends
+// @Description on the last day of the current billing period; at_period_end=false ends it today.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param options body dto.CancelSubscriptionRequest false "Cancel options"
+// @Success 200 {object} dto.Subscription
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 409 {object} map[string]string "Transition not allowed"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/cancel [post]
+func (sc *SubsController) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	var req dto.CancelSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	sub, err := sc.service.CancelSubscription(r.Context(), r.PathValue("id"), req)
+
+	writeStatusChange(w, sub, err)
+}
+
+// @Summary Restore subscription
+// @Description Restore a deleted subscription that has not been purged yet.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} dto.Subscription
+// @Failure 404 {object} map[string]string "Subscription not found or already purged"
+// @Failure 409 {object} map[string]string "Subscription is not deleted"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/restore [post]
+func (sc *SubsController) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := sc.service.RestoreSubscription(r.Context(), r.PathValue("id"))
+
+	writeStatusChange(w, sub, err)
+}
+
+func writeStatusChange(w http.ResponseWriter, sub dto.Subscription, err error) {
+	if errormsgs.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+
+		return
+	}
+
+	if errormsgs.IsConflict(err) {
+		http.Error(w, err.Error(), http.StatusConflict)
+
+		return
+	}
+
+	if errormsgs.IsForbidden(err) {
+		writeForbidden(w, err)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("ETag", dto.SubscriptionETag(sub))
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
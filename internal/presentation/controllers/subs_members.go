@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agl/online_subs/internal/application/dto"
+	"github.com/agl/online_subs/internal/errormsgs"
+)
+
+// writeMemberError maps membership errors: 404 for unknown subscriptions or
+// memberships, 409 for users who are already members and 403 for policy
+// denials.
+func writeMemberError(w http.ResponseWriter, err error) {
+	switch {
+	case errormsgs.IsNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errormsgs.IsConflict(err):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errormsgs.IsForbidden(err):
+		writeForbidden(w, err)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary List subscription members
+// @Description Get the current and past members sharing a subscription. The owner is not listed.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} dto.SubscriptionMember
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/members [get]
+func (sc *SubsController) GetSubscriptionMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := sc.service.GetSubscriptionMembers(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeMemberError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(members); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Add subscription member
+// @Description Share a subscription with another user from effective_date (today if omitted).
+// @Description The cost is split by the subscription split_rule: equally between the owner and members,
+// @Description or by share, a fixed amount per billing period or a percentage; the owner pays the rest.
+// @Description A member pays for the months they are a member of on the last day.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param member body dto.AddSubscriptionMemberRequest true "Member info"
+// @Success 201 {object} dto.SubscriptionMember
+// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 404 {object} map[string]string "Subscription not found"
+// @Failure 409 {object} map[string]string "Already a member"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/members [post]
+func (sc *SubsController) AddSubscriptionMember(w http.ResponseWriter, r *http.Request) {
+	var req dto.AddSubscriptionMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	member, err := sc.service.AddSubscriptionMember(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		writeMemberError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(member); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// @Summary Remove subscription member
+// @Description End a membership from effective_date (today if omitted). The membership stays in the history.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param userUUID path string true "Member user UUID"
+// @Param effective_date query string false "First day the user is no longer a member (YYYY-MM-DD)"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string "Membership not found"
+// @Failure 403 {object} map[string]string "Denied by policy, with a machine-readable reason"
+// @Failure 500 {object} map[string]string "Internal error"
+// @Router /subscriptions/{id}/members/{userUUID} [delete]
+func (sc *SubsController) RemoveSubscriptionMember(w http.ResponseWriter, r *http.Request) {
+	err := sc.service.RemoveSubscriptionMember(r.Context(), r.PathValue("id"), r.PathValue("userUUID"), r.URL.Query().Get("effective_date"))
+	if err != nil {
+		writeMemberError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": "removed"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/agl/online_subs/internal/auth"
+	"github.com/agl/online_subs/internal/errormsgs"
+	"github.com/agl/online_subs/internal/tenant"
+	"github.com/agl/online_subs/pkg/logger"
+)
+
+// withOrganization scopes each request to the organisation of its principal.
+// Tokens that do not name one are denied, unless SINGLE_TENANT is set and
+// they fall back to DEFAULT_ORGANIZATION_ID.
+func withOrganization(next http.Handler) http.Handler {
+	var fallback string
+
+	if singleTenant, _ := strconv.ParseBool(os.Getenv("SINGLE_TENANT")); singleTenant {
+		fallback = os.Getenv("DEFAULT_ORGANIZATION_ID")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.PrincipalFrom(r.Context())
+
+		organizationID := principal.OrganizationID
+		if organizationID == "" {
+			organizationID = fallback
+		}
+
+		if organizationID == "" {
+			logger.Log.Error("No organization for principal", "subject", principal.Subject)
+			writeForbidden(w, errormsgs.Deny("no_organization"))
+
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(tenant.WithOrganization(r.Context(), organizationID)))
+	})
+}
@@ -0,0 +1,20 @@
+// Package tenant carries the organisation a request acts for.
+package tenant
+
+import "context"
+
+// DefaultOrganizationID owns the data that existed before organisations were introduced.
+const DefaultOrganizationID = "00000000-0000-0000-0000-000000000001"
+
+type organizationKey struct{}
+
+func WithOrganization(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationID)
+}
+
+// OrganizationID returns the organisation of ctx, or "" when none was resolved.
+func OrganizationID(ctx context.Context) string {
+	id, _ := ctx.Value(organizationKey{}).(string)
+
+	return id
+}
@@ -0,0 +1,177 @@
+// Package xlsx writes single-sheet XLSX workbooks as a stream: rows go
+// straight to the underlying writer and nothing but the current row is held
+// in memory. Strings are stored inline, so there is no shared string table
+// to build up.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// MaxRows is the number of rows a worksheet can hold.
+const MaxRows = 1 << 20
+
+var ErrTooManyRows = errors.New("xlsx: worksheet row limit exceeded")
+
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+	contentTypes = xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	rootRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbookRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	workbookStart = xmlHeader + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	workbookEnd = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	sheetStart = xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd   = `</sheetData></worksheet>`
+)
+
+// Writer streams the rows of one worksheet.
+type Writer struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	rows  int
+}
+
+// NewWriter writes the workbook parts that precede the rows to w and opens
+// the worksheet, named sheetName.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content func(io.Writer) error
+	}{
+		{"[Content_Types].xml", writeString(contentTypes)},
+		{"_rels/.rels", writeString(rootRels)},
+		{"xl/_rels/workbook.xml.rels", writeString(workbookRels)},
+		{"xl/workbook.xml", func(part io.Writer) error {
+			if _, err := io.WriteString(part, workbookStart); err != nil {
+				return err
+			}
+
+			if err := xml.EscapeText(part, []byte(sheetName)); err != nil {
+				return err
+			}
+
+			_, err := io.WriteString(part, workbookEnd)
+
+			return err
+		}},
+	}
+
+	for _, p := range parts {
+		part, err := zw.Create(p.name)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: failed to create %s: %w", p.name, err)
+		}
+
+		if err := p.content(part); err != nil {
+			return nil, fmt.Errorf("xlsx: failed to write %s: %w", p.name, err)
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: failed to create worksheet: %w", err)
+	}
+
+	if _, err := io.WriteString(sheet, sheetStart); err != nil {
+		return nil, fmt.Errorf("xlsx: failed to write worksheet: %w", err)
+	}
+
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+func writeString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+
+		return err
+	}
+}
+
+// WriteRow appends a row. int, int64 and float64 cells are numbers, nil cells
+// are left empty and anything else is written as text.
+func (w *Writer) WriteRow(cells []any) error {
+	if w.rows == MaxRows {
+		return ErrTooManyRows
+	}
+
+	w.rows++
+
+	if _, err := io.WriteString(w.sheet, `<row r="`+strconv.Itoa(w.rows)+`">`); err != nil {
+		return err
+	}
+
+	for _, cell := range cells {
+		if err := w.writeCell(cell); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w.sheet, `</row>`)
+
+	return err
+}
+
+func (w *Writer) writeCell(cell any) error {
+	var number string
+
+	switch v := cell.(type) {
+	case nil:
+		_, err := io.WriteString(w.sheet, `<c/>`)
+
+		return err
+	case int:
+		number = strconv.Itoa(v)
+	case int64:
+		number = strconv.FormatInt(v, 10)
+	case float64:
+		number = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		if _, err := io.WriteString(w.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+
+		if err := xml.EscapeText(w.sheet, []byte(fmt.Sprint(v))); err != nil {
+			return err
+		}
+
+		_, err := io.WriteString(w.sheet, `</t></is></c>`)
+
+		return err
+	}
+
+	_, err := io.WriteString(w.sheet, `<c><v>`+number+`</v></c>`)
+
+	return err
+}
+
+// Close ends the worksheet and the workbook. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if _, err := io.WriteString(w.sheet, sheetEnd); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
@@ -0,0 +1,104 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// readPart returns the content of the part named name in the workbook.
+func readPart(t *testing.T, workbook []byte, name string) string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+
+	part, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+
+	defer part.Close()
+
+	content, err := io.ReadAll(part)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+
+	return string(content)
+}
+
+func TestWriterRows(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, "Subs & <co>")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	rows := [][]any{
+		{"name", "price"},
+		{"Tom & <Jerry>", 499, int64(7), 12.5, nil},
+		{},
+	}
+
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow(%v): %v", row, err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	want := sheetStart +
+		`<row r="1">` +
+		`<c t="inlineStr"><is><t xml:space="preserve">name</t></is></c>` +
+		`<c t="inlineStr"><is><t xml:space="preserve">price</t></is></c>` +
+		`</row>` +
+		`<row r="2">` +
+		`<c t="inlineStr"><is><t xml:space="preserve">Tom &amp; &lt;Jerry&gt;</t></is></c>` +
+		`<c><v>499</v></c><c><v>7</v></c><c><v>12.5</v></c><c/>` +
+		`</row>` +
+		`<row r="3"></row>` +
+		sheetEnd
+
+	if got := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml"); got != want {
+		t.Errorf("worksheet =\n%s\nwant\n%s", got, want)
+	}
+
+	wantWorkbook := workbookStart + "Subs &amp; &lt;co&gt;" + workbookEnd
+	if got := readPart(t, buf.Bytes(), "xl/workbook.xml"); got != wantWorkbook {
+		t.Errorf("workbook =\n%s\nwant\n%s", got, wantWorkbook)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		readPart(t, buf.Bytes(), name)
+	}
+}
+
+func TestWriterTooManyRows(t *testing.T) {
+	w, err := NewWriter(io.Discard, "Sheet1")
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	for i := 0; i < MaxRows; i++ {
+		if err := w.WriteRow(nil); err != nil {
+			t.Fatalf("WriteRow %d: %v", i+1, err)
+		}
+	}
+
+	if err := w.WriteRow(nil); !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("WriteRow past MaxRows: err = %v, want ErrTooManyRows", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Close after ErrTooManyRows: %v", err)
+	}
+}